@@ -0,0 +1,104 @@
+// Package boards is the registry of zones a card in a deck can be on.
+//
+// A handful of zones are built in. Owners can add their own boards to a
+// deck; those live in the deck_boards table and are checked against it.
+package boards
+
+import (
+	"errors"
+	"strings"
+)
+
+// Built-in board names, as stored in deck_cards.board.
+const (
+	Commander   = "commander"
+	Companion   = "companion"
+	Main        = "main"
+	Sideboard   = "sideboard"
+	Maybeboard  = "maybeboard"
+	Considering = "considering"
+	Tokens      = "tokens"
+)
+
+// MaxNameLength matches the size of the deck_cards.board column.
+const MaxNameLength = 50
+
+// Zone describes a built-in board.
+type Zone struct {
+	Name  string
+	Label string
+	// Managed zones have their own rules and are only changed through
+	// dedicated endpoints, such as choosing commanders.
+	Managed bool
+	// Physical zones hold the cards you bring to the table, so they need
+	// real copies. Maybeboards and tokens do not.
+	Physical bool
+}
+
+// builtins are listed in the order boards are shown in a deck.
+var builtins = []Zone{
+	{Name: Commander, Label: "Commander", Managed: true, Physical: true},
+	{Name: Companion, Label: "Companion", Managed: true, Physical: true},
+	{Name: Main, Label: "Mainboard", Physical: true},
+	{Name: Sideboard, Label: "Sideboard", Physical: true},
+	{Name: Maybeboard, Label: "Maybeboard"},
+	{Name: Considering, Label: "Considering"},
+	{Name: Tokens, Label: "Tokens"},
+}
+
+var (
+	ErrInvalidName = errors.New("board names must be between 1 and 50 characters")
+	ErrReserved    = errors.New("that name is reserved for a built-in board")
+)
+
+// Builtins returns the built-in zones in display order.
+func Builtins() []Zone {
+	return append([]Zone(nil), builtins...)
+}
+
+// Builtin looks up a built-in zone by name.
+func Builtin(name string) (Zone, bool) {
+	for _, z := range builtins {
+		if z.Name == name {
+			return z, true
+		}
+	}
+	return Zone{}, false
+}
+
+// PhysicalNames returns the names of the zones whose cards need real copies.
+func PhysicalNames() []string {
+	var names []string
+	for _, z := range builtins {
+		if z.Physical {
+			names = append(names, z.Name)
+		}
+	}
+	return names
+}
+
+// Position returns the display order of a built-in zone; custom boards sort
+// after all of them.
+func Position(name string) int {
+	for i, z := range builtins {
+		if z.Name == name {
+			return i
+		}
+	}
+	return len(builtins)
+}
+
+// ValidateCustomName checks a name for a user-defined board and returns it
+// trimmed of surrounding whitespace.
+func ValidateCustomName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxNameLength {
+		return "", ErrInvalidName
+	}
+	for _, z := range builtins {
+		if strings.EqualFold(z.Name, name) || strings.EqualFold(z.Label, name) {
+			return "", ErrReserved
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,214 @@
+// Package bracket estimates where a Commander deck sits in the Commander
+// bracket system, from 1 (Exhibition) to 5 (cEDH). It looks for what the
+// system restricts: game changers, mass land denial, extra turns, tutors,
+// fast mana and two-card combos, and explains which cards raised the
+// estimate. Bracket 1 is about a deck's theme rather than its cards, so
+// the estimate starts at 2.
+package bracket
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/manacost"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/tags"
+)
+
+// Brackets, by number.
+const (
+	Exhibition = 1
+	Core       = 2
+	Upgraded   = 3
+	Optimized  = 4
+	CEDH       = 5
+)
+
+var bracketNames = map[int]string{
+	Exhibition: "Exhibition",
+	Core:       "Core",
+	Upgraded:   "Upgraded",
+	Optimized:  "Optimized",
+	CEDH:       "cEDH",
+}
+
+// Name returns the name of a bracket.
+func Name(bracket int) string {
+	return bracketNames[bracket]
+}
+
+// earlyComboManaValue is the most two combo pieces can cost together for
+// the combo to count as an early-game one, which bracket 3 rules out.
+const earlyComboManaValue = 6
+
+// Combo is a set of cards that together win the game or go infinite.
+type Combo struct {
+	Cards  []string `json:"cards"`
+	Result string   `json:"result"`
+}
+
+// KnownCombos returns the two-card combos built into the estimator.
+func KnownCombos() []Combo {
+	combos := make([]Combo, len(knownCombos))
+	for i, combo := range knownCombos {
+		combo.Cards = append([]string(nil), combo.Cards...)
+		combos[i] = combo
+	}
+	return combos
+}
+
+var (
+	extraTurnPattern      = regexp.MustCompile(`takes? (an|two|\w+) extra turns?`)
+	massLandDenialPattern = regexp.MustCompile(`destroy all (\w+ )?lands|(each player|each opponent|all players) sacrifices? [^.]*lands|lands? (don't|do not) untap|return all lands|nonbasic lands are mountains|exile all lands`)
+)
+
+// Estimate places a deck in a bracket. The cards are the commander zone
+// and mainboard, each with its functional tags applied. combos are the
+// two-card combos to look for; a combo listed twice counts once.
+func Estimate(cards []models.Card, combos []Combo) models.BracketEstimate {
+	byName := make(map[string]models.Card, len(cards))
+	var gameChanging, fast, landDenial, extraTurns, tutors []string
+	for _, card := range cards {
+		key := normalize(card.Name)
+		if _, seen := byName[key]; seen {
+			continue
+		}
+		byName[key] = card
+		text := strings.ToLower(card.OracleText)
+
+		if gameChangers[key] {
+			gameChanging = append(gameChanging, card.Name)
+		}
+		if fastMana[key] {
+			fast = append(fast, card.Name)
+		}
+		if massLandDenial[key] || massLandDenialPattern.MatchString(text) {
+			landDenial = append(landDenial, card.Name)
+		}
+		if extraTurnPattern.MatchString(text) {
+			extraTurns = append(extraTurns, card.Name)
+		}
+		if tags.Has(card, tags.Tutor) {
+			tutors = append(tutors, card.Name)
+		}
+	}
+
+	var reasons []models.BracketReason
+	add := func(rule string, bracket int, cards []string, explanation string) {
+		sort.Strings(cards)
+		reasons = append(reasons, models.BracketReason{Rule: rule, Bracket: bracket, Explanation: explanation, Cards: cards})
+	}
+
+	switch n := len(gameChanging); {
+	case n > 3:
+		add("game_changers", Optimized, gameChanging, fmt.Sprintf("%d game changers; bracket 3 allows up to three.", n))
+	case n > 1:
+		add("game_changers", Upgraded, gameChanging, fmt.Sprintf("%d game changers; brackets 1 and 2 allow none.", n))
+	case n == 1:
+		add("game_changers", Upgraded, gameChanging, "A game changer; brackets 1 and 2 allow none.")
+	}
+
+	if len(landDenial) > 0 {
+		add("mass_land_denial", Optimized, landDenial, "Mass land denial is only expected from bracket 4.")
+	}
+
+	switch n := len(extraTurns); {
+	case n >= 3:
+		add("extra_turns", Optimized, extraTurns, fmt.Sprintf("%d extra-turn cards are enough to chain extra turns, which brackets 1 to 3 rule out.", n))
+	case n > 0:
+		add("extra_turns", Core, extraTurns, "A few extra-turn cards are fine as long as they are not chained.")
+	}
+
+	switch n := len(tutors); {
+	case n >= 6:
+		add("tutors", Optimized, tutors, fmt.Sprintf("%d tutors make the deck very consistent.", n))
+	case n >= 3:
+		add("tutors", Upgraded, tutors, fmt.Sprintf("%d tutors; bracket 2 decks run few.", n))
+	case n > 0:
+		add("tutors", Core, tutors, "A tutor or two is within bracket 2.")
+	}
+
+	switch n := len(fast); {
+	case n >= 3:
+		add("fast_mana", Optimized, fast, fmt.Sprintf("%d pieces of fast mana accelerate the deck well past the table.", n))
+	case n > 0:
+		add("fast_mana", Upgraded, fast, "Fast mana lets the deck start ahead of the table.")
+	}
+
+	var early, late []string
+	counted := make(map[string]bool, len(combos))
+	for _, combo := range combos {
+		keys := make([]string, len(combo.Cards))
+		for i, name := range combo.Cards {
+			keys[i] = normalize(name)
+		}
+		sort.Strings(keys)
+		key := strings.Join(keys, "+")
+		if counted[key] {
+			continue
+		}
+		counted[key] = true
+
+		total, complete := 0.0, true
+		for _, name := range combo.Cards {
+			card, ok := byName[normalize(name)]
+			if !ok {
+				complete = false
+				break
+			}
+			if cost, err := manacost.Parse(card.ManaCost); err == nil {
+				total += cost.ManaValue()
+			}
+		}
+		if !complete {
+			continue
+		}
+		label := strings.Join(combo.Cards, " + ")
+		if total <= earlyComboManaValue {
+			early = append(early, label)
+		} else {
+			late = append(late, label)
+		}
+	}
+	switch {
+	case len(early) > 0:
+		add("two_card_combos", Optimized, append(early, late...), "Two-card combos that can come together early in the game.")
+	case len(late) > 0:
+		add("two_card_combos", Upgraded, late, "Two-card combos, though only late in the game; brackets 1 and 2 allow none.")
+	}
+
+	// Everything at once is the mark of a deck built for competitive play.
+	if len(gameChanging) >= 6 && len(fast) >= 4 && (len(early) > 0 || len(tutors) >= 6) {
+		add("cedh", CEDH, dedupe(append(append([]string(nil), gameChanging...), fast...)),
+			"Many game changers, fast mana and tutors or early combos: this looks built for cEDH.")
+	}
+
+	estimate := models.BracketEstimate{Bracket: Core, Reasons: make([]models.BracketReason, 0, len(reasons))}
+	for _, reason := range reasons {
+		estimate.Bracket = max(estimate.Bracket, reason.Bracket)
+		estimate.Reasons = append(estimate.Reasons, reason)
+	}
+	estimate.Name = Name(estimate.Bracket)
+	return estimate
+}
+
+// normalize is the key cards are matched by: the lower-cased name of the
+// front face.
+func normalize(name string) string {
+	front, _, _ := strings.Cut(name, " // ")
+	return strings.ToLower(strings.TrimSpace(front))
+}
+
+func dedupe(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	result := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
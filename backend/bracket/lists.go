@@ -0,0 +1,76 @@
+package bracket
+
+// The card lists below follow the Commander bracket announcements of 2025.
+// They change from time to time; update them here.
+
+// gameChangers are the cards the bracket system singles out as warping
+// games. Bracket 3 allows up to three of them.
+var gameChangers = names(
+	// White
+	"Drannith Magistrate", "Enlightened Tutor", "Humility", "Serra's Sanctum", "Smothering Tithe", "Teferi's Protection",
+	// Blue
+	"Consecrated Sphinx", "Cyclonic Rift", "Fierce Guardianship", "Force of Will", "Gifts Ungiven", "Intuition",
+	"Jin-Gitaxias, Core Augur", "Mystical Tutor", "Narset, Parter of Veils", "Rhystic Study", "Sway of the Stars",
+	"Thassa's Oracle", "Urza, Lord High Artificer",
+	// Black
+	"Ad Nauseam", "Bolas's Citadel", "Braids, Cabal Minion", "Demonic Tutor", "Imperial Seal", "Necropotence",
+	"Opposition Agent", "Orcish Bowmasters", "Tergrid, God of Fright", "Vampiric Tutor",
+	// Red
+	"Gamble", "Jeska's Will", "Underworld Breach",
+	// Green
+	"Crop Rotation", "Food Chain", "Gaea's Cradle", "Natural Order", "Seedborn Muse", "Survival of the Fittest",
+	"Vorinclex, Voice of Hunger", "Worldly Tutor",
+	// Multicolor
+	"Aura Shards", "Coalition Victory", "Grand Arbiter Augustin IV", "Kinnan, Bonder Prodigy", "Notion Thief",
+	"Winota, Joiner of Forces", "Yuriko, the Tiger's Shadow",
+	// Colorless
+	"Ancient Tomb", "Chrome Mox", "Field of the Dead", "Glacial Chasm", "Grim Monolith", "Lion's Eye Diamond",
+	"Mana Vault", "Mishra's Workshop", "Mox Diamond", "Panoptic Mirror", "The One Ring", "The Tabernacle at Pendrell Vale",
+)
+
+// fastMana are cards that make more mana than they cost early on. Sol Ring
+// is played at every bracket and is left out.
+var fastMana = names(
+	"Mana Crypt", "Mana Vault", "Grim Monolith", "Chrome Mox", "Mox Diamond", "Mox Opal", "Mox Amber",
+	"Jeweled Lotus", "Lotus Petal", "Lion's Eye Diamond", "Ancient Tomb", "Mishra's Workshop", "Gaea's Cradle",
+	"Elvish Spirit Guide", "Simian Spirit Guide", "Dark Ritual", "Cabal Ritual", "Rite of Flame", "Culling the Weak",
+)
+
+// massLandDenial are cards that destroy, lock down or strip lands en masse
+// and are not caught by the text rules.
+var massLandDenial = names(
+	"Blood Moon", "Magus of the Moon", "Back to Basics", "Winter Orb", "Static Orb", "Hokori, Dust Drinker",
+	"Rising Waters", "Stasis", "Contamination", "Tangle Wire",
+)
+
+// knownCombos are well-known pairs of cards that win the game or go
+// infinite on their own.
+var knownCombos = []Combo{
+	{Cards: []string{"Thassa's Oracle", "Demonic Consultation"}, Result: "Win the game"},
+	{Cards: []string{"Thassa's Oracle", "Tainted Pact"}, Result: "Win the game"},
+	{Cards: []string{"Laboratory Maniac", "Demonic Consultation"}, Result: "Win the game"},
+	{Cards: []string{"Isochron Scepter", "Dramatic Reversal"}, Result: "Infinite mana with mana rocks"},
+	{Cards: []string{"Kiki-Jiki, Mirror Breaker", "Zealous Conscripts"}, Result: "Infinite hasty tokens"},
+	{Cards: []string{"Kiki-Jiki, Mirror Breaker", "Pestermite"}, Result: "Infinite hasty tokens"},
+	{Cards: []string{"Kiki-Jiki, Mirror Breaker", "Deceiver Exarch"}, Result: "Infinite hasty tokens"},
+	{Cards: []string{"Splinter Twin", "Pestermite"}, Result: "Infinite hasty tokens"},
+	{Cards: []string{"Splinter Twin", "Deceiver Exarch"}, Result: "Infinite hasty tokens"},
+	{Cards: []string{"Heliod, Sun-Crowned", "Walking Ballista"}, Result: "Infinite damage"},
+	{Cards: []string{"Dualcaster Mage", "Twinflame"}, Result: "Infinite hasty tokens"},
+	{Cards: []string{"Exquisite Blood", "Sanguine Bond"}, Result: "Infinite life drain"},
+	{Cards: []string{"Niv-Mizzet, Parun", "Curiosity"}, Result: "Infinite damage and card draw"},
+	{Cards: []string{"Basalt Monolith", "Rings of Brighthearth"}, Result: "Infinite colorless mana"},
+	{Cards: []string{"Mikaeus, the Unhallowed", "Triskelion"}, Result: "Infinite damage"},
+	{Cards: []string{"Painter's Servant", "Grindstone"}, Result: "Mill each opponent's library"},
+	{Cards: []string{"Peregrine Drake", "Deadeye Navigator"}, Result: "Infinite mana"},
+	{Cards: []string{"Food Chain", "Eternal Scourge"}, Result: "Infinite creature mana"},
+	{Cards: []string{"Worldgorger Dragon", "Animate Dead"}, Result: "Infinite mana and triggers"},
+}
+
+func names(list ...string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, name := range list {
+		set[normalize(name)] = true
+	}
+	return set
+}
@@ -0,0 +1,125 @@
+package cardsource
+
+import (
+	"context"
+	"errors"
+
+	"mana-tomb/backend/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// upsertCardQuery inserts a card or refreshes the cached row. Rows that
+// already hold identical data are left untouched, so RowsAffected reports
+// only cards that were new or changed.
+const upsertCardQuery = `
+	INSERT INTO cards (scryfall_id, name, image_uris, mana_cost, cmc, type_line, oracle_text, colors, color_identity, set_code, collector_number)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT (scryfall_id) DO UPDATE SET
+		name = EXCLUDED.name,
+		image_uris = EXCLUDED.image_uris,
+		mana_cost = EXCLUDED.mana_cost,
+		cmc = EXCLUDED.cmc,
+		type_line = EXCLUDED.type_line,
+		oracle_text = EXCLUDED.oracle_text,
+		colors = EXCLUDED.colors,
+		color_identity = EXCLUDED.color_identity,
+		set_code = EXCLUDED.set_code,
+		collector_number = EXCLUDED.collector_number
+	WHERE (cards.name, cards.image_uris, cards.mana_cost, cards.cmc, cards.type_line, cards.oracle_text,
+	       cards.colors, cards.color_identity, cards.set_code, cards.collector_number)
+	IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.image_uris, EXCLUDED.mana_cost, EXCLUDED.cmc, EXCLUDED.type_line, EXCLUDED.oracle_text,
+	       EXCLUDED.colors, EXCLUDED.color_identity, EXCLUDED.set_code, EXCLUDED.collector_number)
+`
+
+func upsertArgs(card models.Card) []any {
+	return []any{
+		card.ScryfallID, card.Name, card.ImageURIs, card.ManaCost, card.CMC, card.TypeLine, card.OracleText,
+		card.Colors, card.ColorIdentity, card.SetCode, card.CollectorNumber,
+	}
+}
+
+// Upsert stores a card in the cards table inside the caller's transaction.
+func Upsert(ctx context.Context, tx pgx.Tx, card models.Card) error {
+	_, err := tx.Exec(ctx, upsertCardQuery, upsertArgs(card)...)
+	return err
+}
+
+// QueueUpsert adds a card upsert to a batch, for bulk loads.
+func QueueUpsert(batch *pgx.Batch, card models.Card) *pgx.QueuedQuery {
+	return batch.Queue(upsertCardQuery, upsertArgs(card)...)
+}
+
+// Database serves cards from our own cards table. Once the table has been
+// filled by cmd/cardsync it makes search and card lookups work offline.
+type Database struct {
+	dbpool *pgxpool.Pool
+}
+
+// NewDatabase returns a card source backed by the cards table.
+func NewDatabase(dbpool *pgxpool.Pool) *Database {
+	return &Database{dbpool: dbpool}
+}
+
+const cardColumns = `scryfall_id, name, image_uris, mana_cost, cmc, type_line, oracle_text, colors, color_identity, set_code, collector_number`
+
+func scanCard(row pgx.Row) (models.Card, error) {
+	var card models.Card
+	err := row.Scan(&card.ScryfallID, &card.Name, &card.ImageURIs, &card.ManaCost, &card.CMC, &card.TypeLine, &card.OracleText,
+		&card.Colors, &card.ColorIdentity, &card.SetCode, &card.CollectorNumber)
+	return card, err
+}
+
+// Search implements CardSource. It matches the query anywhere in the card
+// name and returns one printing per distinct name.
+func (d *Database) Search(ctx context.Context, query string, page int) (SearchResult, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(DISTINCT name) FROM cards WHERE strpos(lower(name), lower($1)) > 0`
+	if err := d.dbpool.QueryRow(ctx, countQuery, query).Scan(&total); err != nil {
+		return SearchResult{}, err
+	}
+
+	searchQuery := `
+		SELECT DISTINCT ON (name) ` + cardColumns + `
+		FROM cards
+		WHERE strpos(lower(name), lower($1)) > 0
+		ORDER BY name, set_code, collector_number
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := d.dbpool.Query(ctx, searchQuery, query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	defer rows.Close()
+
+	result := SearchResult{Cards: make([]models.Card, 0), TotalCards: total}
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		result.Cards = append(result.Cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return SearchResult{}, err
+	}
+
+	result.HasMore = page*pageSize < total
+	return result, nil
+}
+
+// Card implements CardSource.
+func (d *Database) Card(ctx context.Context, id uuid.UUID) (models.Card, error) {
+	row := d.dbpool.QueryRow(ctx, `SELECT `+cardColumns+` FROM cards WHERE scryfall_id = $1`, id)
+	card, err := scanCard(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Card{}, ErrNotFound
+	}
+	return card, err
+}
@@ -0,0 +1,98 @@
+package cardsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// pageSize matches the page size of Scryfall's search endpoint.
+const pageSize = 175
+
+// File serves cards from a JSON file of Scryfall card objects, either a
+// bare array or a Scryfall list object with a "data" field. It needs no
+// network access, which makes it suitable for offline development and
+// tests. Search matches the query against card names, ignoring case.
+type File struct {
+	cards []models.Card
+	byID  map[uuid.UUID]models.Card
+}
+
+// NewFile loads every card from the file at path into memory.
+func NewFile(path string) (*File, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scryfallCards []ScryfallCard
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var list struct {
+			Data []ScryfallCard `json:"data"`
+		}
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return nil, fmt.Errorf("cardsource: reading %s: %w", path, err)
+		}
+		scryfallCards = list.Data
+	} else if err := json.Unmarshal(trimmed, &scryfallCards); err != nil {
+		return nil, fmt.Errorf("cardsource: reading %s: %w", path, err)
+	}
+
+	f := &File{
+		cards: make([]models.Card, 0, len(scryfallCards)),
+		byID:  make(map[uuid.UUID]models.Card, len(scryfallCards)),
+	}
+	for _, sc := range scryfallCards {
+		card := sc.ToCard()
+		f.cards = append(f.cards, card)
+		f.byID[card.ScryfallID] = card
+	}
+	sort.Slice(f.cards, func(i, j int) bool { return f.cards[i].Name < f.cards[j].Name })
+	return f, nil
+}
+
+// Search implements CardSource.
+func (f *File) Search(ctx context.Context, query string, page int) (SearchResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	needle := strings.ToLower(strings.TrimSpace(query))
+
+	matches := make([]models.Card, 0)
+	for _, card := range f.cards {
+		if strings.Contains(strings.ToLower(card.Name), needle) {
+			matches = append(matches, card)
+		}
+	}
+
+	start := (page - 1) * pageSize
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return SearchResult{
+		Cards:      matches[start:end],
+		TotalCards: len(matches),
+		HasMore:    end < len(matches),
+	}, nil
+}
+
+// Card implements CardSource.
+func (f *File) Card(ctx context.Context, id uuid.UUID) (models.Card, error) {
+	card, ok := f.byID[id]
+	if !ok {
+		return models.Card{}, ErrNotFound
+	}
+	return card, nil
+}
@@ -0,0 +1,196 @@
+package cardsource
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Prices are the market prices of one printing. A nil price means the
+// printing has no price in that currency or finish.
+type Prices struct {
+	USD     *float64
+	USDFoil *float64
+	EUR     *float64
+	EURFoil *float64
+	TIX     *float64
+}
+
+// scryfallPrices is the "prices" object of a Scryfall card, which reports
+// prices as decimal strings.
+type scryfallPrices struct {
+	USD     *string `json:"usd"`
+	USDFoil *string `json:"usd_foil"`
+	EUR     *string `json:"eur"`
+	EURFoil *string `json:"eur_foil"`
+	TIX     *string `json:"tix"`
+}
+
+// ToPrices returns the card's prices. Prices Scryfall left empty or sent in
+// an unexpected form are treated as missing.
+func (sc ScryfallCard) ToPrices() Prices {
+	parse := func(s *string) *float64 {
+		if s == nil {
+			return nil
+		}
+		f, err := parsePrice(*s)
+		if err != nil {
+			return nil
+		}
+		return f
+	}
+	return Prices{
+		USD:     parse(sc.Prices.USD),
+		USDFoil: parse(sc.Prices.USDFoil),
+		EUR:     parse(sc.Prices.EUR),
+		EURFoil: parse(sc.Prices.EURFoil),
+		TIX:     parse(sc.Prices.TIX),
+	}
+}
+
+// Empty reports whether no price is set.
+func (p Prices) Empty() bool {
+	return p.USD == nil && p.USDFoil == nil && p.EUR == nil && p.EURFoil == nil && p.TIX == nil
+}
+
+// parsePrice reads a price such as "1.25" or "$1.25". An empty string is no
+// price.
+func parsePrice(s string) (*float64, error) {
+	s = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(s), "$€"))
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return nil, fmt.Errorf("invalid price %q", s)
+	}
+	return &f, nil
+}
+
+// PriceRow is one printing's prices read from a price CSV.
+type PriceRow struct {
+	ScryfallID uuid.UUID
+	Prices     Prices
+}
+
+var priceColumns = map[string]string{
+	"scryfall_id": "id",
+	"scryfall id": "id",
+	"id":          "id",
+	"usd":         "usd",
+	"usd_foil":    "usd_foil",
+	"usd foil":    "usd_foil",
+	"eur":         "eur",
+	"eur_foil":    "eur_foil",
+	"eur foil":    "eur_foil",
+	"tix":         "tix",
+}
+
+// ReadPriceCSV reads a price CSV. The header names the columns: a Scryfall
+// ID column ("scryfall_id") and any of "usd", "usd_foil", "eur", "eur_foil"
+// and "tix". Empty cells are missing prices. The first bad row stops the
+// read with an error naming its line.
+func ReadPriceCSV(r io.Reader) ([]PriceRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the price CSV is empty")
+		}
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if key, ok := priceColumns[name]; ok {
+			columns[key] = i
+		}
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, errors.New("the price CSV needs a \"scryfall_id\" column")
+	}
+
+	var rows []PriceRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(key string) string {
+			i, ok := columns[key]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+
+		id, err := uuid.Parse(strings.TrimSpace(field("id")))
+		if err != nil {
+			return rows, fmt.Errorf("line %d: invalid Scryfall ID", line)
+		}
+		row := PriceRow{ScryfallID: id}
+		for _, col := range []struct {
+			key string
+			dst **float64
+		}{
+			{"usd", &row.Prices.USD},
+			{"usd_foil", &row.Prices.USDFoil},
+			{"eur", &row.Prices.EUR},
+			{"eur_foil", &row.Prices.EURFoil},
+			{"tix", &row.Prices.TIX},
+		} {
+			if *col.dst, err = parsePrice(field(col.key)); err != nil {
+				return rows, fmt.Errorf("line %d: %s: %w", line, col.key, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// updatePricesQuery sets a card's current prices unless newer ones are
+// already stored.
+const updatePricesQuery = `
+	UPDATE cards
+	SET price_usd = $2, price_usd_foil = $3, price_eur = $4, price_eur_foil = $5, price_tix = $6, prices_updated_on = $7
+	WHERE scryfall_id = $1 AND (prices_updated_on IS NULL OR prices_updated_on <= $7)
+`
+
+// recordPricesQuery keeps the day's prices in the history. Prices of cards
+// that are not in the cache are dropped.
+const recordPricesQuery = `
+	INSERT INTO card_prices (card_scryfall_id, priced_on, usd, usd_foil, eur, eur_foil, tix)
+	SELECT $1::uuid, $7::date, $2::numeric, $3::numeric, $4::numeric, $5::numeric, $6::numeric
+	WHERE EXISTS (SELECT 1 FROM cards WHERE scryfall_id = $1)
+	ON CONFLICT (card_scryfall_id, priced_on) DO UPDATE SET
+		usd = EXCLUDED.usd,
+		usd_foil = EXCLUDED.usd_foil,
+		eur = EXCLUDED.eur,
+		eur_foil = EXCLUDED.eur_foil,
+		tix = EXCLUDED.tix
+`
+
+// QueuePrices adds the statements that store a card's prices for the given
+// day to a batch: the current prices on the card and a row of price
+// history. The returned query is the history insert, whose row count tells
+// whether the card was known.
+func QueuePrices(batch *pgx.Batch, id uuid.UUID, prices Prices, day time.Time) *pgx.QueuedQuery {
+	y, m, d := day.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	args := []any{id, prices.USD, prices.USDFoil, prices.EUR, prices.EURFoil, prices.TIX, date}
+	batch.Queue(updatePricesQuery, args...)
+	return batch.Queue(recordPricesQuery, args...)
+}
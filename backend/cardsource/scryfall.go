@@ -0,0 +1,135 @@
+package cardsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"mana-tomb/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Scryfall asks clients to wait 50-100 milliseconds between requests.
+// See https://scryfall.com/docs/api#rate-limits-and-good-citizenship.
+const scryfallRequestInterval = 100 * time.Millisecond
+
+// Scryfall fetches cards from the public Scryfall API. It is safe for
+// concurrent use; requests are spaced out to respect the rate limit.
+type Scryfall struct {
+	baseURL string
+	client  *http.Client
+
+	mu          sync.Mutex
+	nextRequest time.Time
+}
+
+// NewScryfall returns a Scryfall source that talks to api.scryfall.com.
+func NewScryfall() *Scryfall {
+	return &Scryfall{
+		baseURL: "https://api.scryfall.com",
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Search implements CardSource.
+func (s *Scryfall) Search(ctx context.Context, query string, page int) (SearchResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("page", strconv.Itoa(page))
+
+	var list struct {
+		Data       []ScryfallCard `json:"data"`
+		TotalCards int            `json:"total_cards"`
+		HasMore    bool           `json:"has_more"`
+	}
+	err := s.get(ctx, "/cards/search?"+params.Encode(), &list)
+	if errors.Is(err, ErrNotFound) {
+		// Scryfall answers a search without matches with a 404.
+		return SearchResult{Cards: []models.Card{}}, nil
+	}
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	result := SearchResult{
+		Cards:      make([]models.Card, 0, len(list.Data)),
+		TotalCards: list.TotalCards,
+		HasMore:    list.HasMore,
+	}
+	for _, sc := range list.Data {
+		result.Cards = append(result.Cards, sc.ToCard())
+	}
+	return result, nil
+}
+
+// Card implements CardSource.
+func (s *Scryfall) Card(ctx context.Context, id uuid.UUID) (models.Card, error) {
+	var sc ScryfallCard
+	if err := s.get(ctx, "/cards/"+id.String(), &sc); err != nil {
+		return models.Card{}, err
+	}
+	return sc.ToCard(), nil
+}
+
+func (s *Scryfall) get(ctx context.Context, path string, out any) error {
+	if err := s.wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	// Scryfall requires both headers on every request.
+	req.Header.Set("User-Agent", "ManaTomb/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("scryfall: unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// wait blocks until this client may send its next request.
+func (s *Scryfall) wait(ctx context.Context) error {
+	s.mu.Lock()
+	now := time.Now()
+	start := s.nextRequest
+	if start.Before(now) {
+		start = now
+	}
+	s.nextRequest = start.Add(scryfallRequestInterval)
+	s.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
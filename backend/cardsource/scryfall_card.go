@@ -0,0 +1,85 @@
+package cardsource
+
+import (
+	"encoding/json"
+	"strings"
+
+	"mana-tomb/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// ScryfallCard is the subset of a Scryfall card object that we cache.
+// See https://scryfall.com/docs/api/cards.
+type ScryfallCard struct {
+	ID              uuid.UUID       `json:"id"`
+	Name            string          `json:"name"`
+	ImageURIs       json.RawMessage `json:"image_uris"`
+	ManaCost        string          `json:"mana_cost"`
+	CMC             float32         `json:"cmc"`
+	TypeLine        string          `json:"type_line"`
+	OracleText      string          `json:"oracle_text"`
+	Colors          []string        `json:"colors"`
+	ColorIdentity   []string        `json:"color_identity"`
+	Set             string          `json:"set"`
+	CollectorNumber string          `json:"collector_number"`
+	Prices          scryfallPrices  `json:"prices"`
+	CardFaces       []struct {
+		Name       string          `json:"name"`
+		ImageURIs  json.RawMessage `json:"image_uris"`
+		ManaCost   string          `json:"mana_cost"`
+		TypeLine   string          `json:"type_line"`
+		OracleText string          `json:"oracle_text"`
+		Colors     []string        `json:"colors"`
+	} `json:"card_faces"`
+}
+
+// ToCard converts a Scryfall card into our model. Double-faced and split
+// cards keep most of their details on card_faces, so those are folded into
+// the top-level fields when Scryfall leaves them empty.
+func (sc ScryfallCard) ToCard() models.Card {
+	card := models.Card{
+		ScryfallID:      sc.ID,
+		Name:            sc.Name,
+		ImageURIs:       sc.ImageURIs,
+		ManaCost:        sc.ManaCost,
+		CMC:             sc.CMC,
+		TypeLine:        sc.TypeLine,
+		OracleText:      sc.OracleText,
+		Colors:          sc.Colors,
+		ColorIdentity:   sc.ColorIdentity,
+		SetCode:         sc.Set,
+		CollectorNumber: sc.CollectorNumber,
+	}
+
+	if len(sc.CardFaces) > 0 {
+		front := sc.CardFaces[0]
+		if len(card.ImageURIs) == 0 {
+			card.ImageURIs = front.ImageURIs
+		}
+		if card.ManaCost == "" {
+			card.ManaCost = front.ManaCost
+		}
+		if card.TypeLine == "" {
+			card.TypeLine = front.TypeLine
+		}
+		if card.Colors == nil {
+			card.Colors = front.Colors
+		}
+		if card.OracleText == "" {
+			texts := make([]string, 0, len(sc.CardFaces))
+			for _, face := range sc.CardFaces {
+				texts = append(texts, face.OracleText)
+			}
+			card.OracleText = strings.Join(texts, "\n//\n")
+		}
+	}
+
+	if card.Colors == nil {
+		card.Colors = []string{}
+	}
+	if card.ColorIdentity == nil {
+		card.ColorIdentity = []string{}
+	}
+	return card
+}
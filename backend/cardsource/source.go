@@ -0,0 +1,31 @@
+// Package cardsource looks up authoritative card data on the server so
+// handlers never have to trust card details sent by a client.
+package cardsource
+
+import (
+	"context"
+	"errors"
+
+	"mana-tomb/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a source has no card with the requested ID.
+var ErrNotFound = errors.New("card not found")
+
+// SearchResult is one page of search results.
+type SearchResult struct {
+	Cards      []models.Card `json:"data"`
+	TotalCards int           `json:"total_cards"`
+	HasMore    bool          `json:"has_more"`
+}
+
+// CardSource is anything that can find cards by search query or Scryfall ID.
+type CardSource interface {
+	// Search runs a Scryfall-style search. Pages start at 1. A query with
+	// no matches returns an empty result, not an error.
+	Search(ctx context.Context, query string, page int) (SearchResult, error)
+	// Card fetches a single card, returning ErrNotFound if it does not exist.
+	Card(ctx context.Context, id uuid.UUID) (models.Card, error)
+}
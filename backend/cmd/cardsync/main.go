@@ -0,0 +1,257 @@
+// Command cardsync loads a Scryfall bulk-data file into the cards table.
+//
+// Download a "Default Cards", "Oracle Cards" or "All Cards" file from
+// https://scryfall.com/docs/api/bulk-data and run, from the backend directory:
+//
+//	go run ./cmd/cardsync -file default-cards.json
+//
+// The file is streamed one card at a time, so multi-gigabyte files do not
+// need to fit in memory. Gzipped files (*.gz) are read directly. Cards that
+// are new or whose data changed are written; identical rows are skipped.
+//
+// The prices in the file are stored too, both as the cards' current prices
+// and as a dated row of price history. Prices from another source can be
+// loaded from a CSV with a "scryfall_id" column and any of "usd",
+// "usd_foil", "eur", "eur_foil" and "tix":
+//
+//	go run ./cmd/cardsync -prices prices.csv -date 2024-06-01
+//
+// -date defaults to today. Older prices never replace newer current prices,
+// so past files can be loaded to fill in the history.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"mana-tomb/backend/cardsource"
+	"mana-tomb/backend/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "path to a Scryfall bulk-data JSON file")
+	pricesFile := flag.String("prices", "", "path to a price CSV")
+	dateFlag := flag.String("date", "", "date the prices were taken, as YYYY-MM-DD (default today)")
+	batchSize := flag.Int("batch", 1000, "number of cards to upsert per transaction")
+	progressEvery := flag.Duration("progress", 5*time.Second, "how often to report progress")
+	flag.Parse()
+
+	if *file == "" && *pricesFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *batchSize < 1 {
+		log.Fatal("-batch must be at least 1")
+	}
+	day := time.Now()
+	if *dateFlag != "" {
+		var err error
+		if day, err = time.Parse(time.DateOnly, *dateFlag); err != nil {
+			log.Fatal("-date must be a date such as 2024-06-01")
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables from OS")
+	}
+
+	ctx := context.Background()
+	dbpool, err := database.Connect(ctx)
+	if err != nil {
+		log.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer dbpool.Close()
+
+	if *file != "" {
+		start := time.Now()
+		stats, err := syncFile(ctx, dbpool, *file, day, *batchSize, *progressEvery)
+		if err != nil {
+			log.Fatalf("Card sync failed after %d cards: %v", stats.read, err)
+		}
+		log.Printf("Done in %s: %d cards read, %d written, %d unchanged, %d priced",
+			time.Since(start).Round(time.Second), stats.read, stats.written, stats.read-stats.written, stats.priced)
+	}
+
+	if *pricesFile != "" {
+		stats, err := syncPrices(ctx, dbpool, *pricesFile, day, *batchSize)
+		if err != nil {
+			log.Fatalf("Price sync failed: %v", err)
+		}
+		log.Printf("Prices: %d rows read, %d stored, %d for unknown cards", stats.read, stats.priced, stats.read-stats.priced)
+	}
+}
+
+type syncStats struct {
+	read    int64
+	written int64
+	priced  int64
+}
+
+// countingReader tracks how many bytes of the file have been consumed.
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n.Add(int64(n))
+	return n, err
+}
+
+func syncFile(ctx context.Context, dbpool *pgxpool.Pool, path string, day time.Time, batchSize int, progressEvery time.Duration) (syncStats, error) {
+	var stats syncStats
+
+	f, err := os.Open(path)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return stats, err
+	}
+
+	// Progress is measured on the file as stored, so it stays accurate for
+	// gzipped input too.
+	counter := &countingReader{r: f}
+	var r io.Reader = bufio.NewReaderSize(counter, 1<<20)
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return stats, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return stats, fmt.Errorf("reading start of file: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return stats, fmt.Errorf("expected a JSON array of cards, got %v", tok)
+	}
+
+	batch := &pgx.Batch{}
+	pending := 0
+	lastReport := time.Now()
+	report := func() {
+		pct := 0.0
+		if info.Size() > 0 {
+			pct = float64(counter.n.Load()) / float64(info.Size()) * 100
+		}
+		log.Printf("%5.1f%%  %d cards read, %d written", pct, stats.read, stats.written)
+	}
+
+	for dec.More() {
+		var sc cardsource.ScryfallCard
+		if err := dec.Decode(&sc); err != nil {
+			return stats, fmt.Errorf("decoding card %d: %w", stats.read+1, err)
+		}
+		stats.read++
+
+		cardsource.QueueUpsert(batch, sc.ToCard()).Exec(func(tag pgconn.CommandTag) error {
+			stats.written += tag.RowsAffected()
+			return nil
+		})
+		if prices := sc.ToPrices(); !prices.Empty() {
+			cardsource.QueuePrices(batch, sc.ID, prices, day).Exec(func(tag pgconn.CommandTag) error {
+				stats.priced += tag.RowsAffected()
+				return nil
+			})
+		}
+		// A card queues up to three statements; the batch size counts cards.
+		if pending++; pending >= batchSize {
+			if err := flush(ctx, dbpool, batch); err != nil {
+				return stats, err
+			}
+			batch, pending = &pgx.Batch{}, 0
+		}
+
+		if time.Since(lastReport) >= progressEvery {
+			report()
+			lastReport = time.Now()
+		}
+	}
+
+	if batch.Len() > 0 {
+		if err := flush(ctx, dbpool, batch); err != nil {
+			return stats, err
+		}
+	}
+	report()
+
+	return stats, nil
+}
+
+// syncPrices loads a price CSV. Rows for cards that are not in the cards
+// table are skipped, so cardsync -file should run first.
+func syncPrices(ctx context.Context, dbpool *pgxpool.Pool, path string, day time.Time, batchSize int) (syncStats, error) {
+	var stats syncStats
+
+	f, err := os.Open(path)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	rows, err := cardsource.ReadPriceCSV(bufio.NewReader(f))
+	if err != nil {
+		return stats, err
+	}
+
+	batch := &pgx.Batch{}
+	pending := 0
+	for _, row := range rows {
+		stats.read++
+		cardsource.QueuePrices(batch, row.ScryfallID, row.Prices, day).Exec(func(tag pgconn.CommandTag) error {
+			stats.priced += tag.RowsAffected()
+			return nil
+		})
+		if pending++; pending >= batchSize {
+			if err := flush(ctx, dbpool, batch); err != nil {
+				return stats, err
+			}
+			batch, pending = &pgx.Batch{}, 0
+		}
+	}
+	if batch.Len() > 0 {
+		if err := flush(ctx, dbpool, batch); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// flush runs a batch in one transaction. Row counts are reported through
+// the callbacks registered on the queued queries.
+func flush(ctx context.Context, dbpool *pgxpool.Pool, batch *pgx.Batch) error {
+	tx, err := dbpool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,158 @@
+// Command combosync loads a combo dataset into the combos and combo_cards
+// tables, replacing the combos stored before. Run, from the backend
+// directory:
+//
+//	go run ./cmd/combosync -file combos.json
+//
+// The file is a JSON array of combos; gzipped files (*.gz) are read
+// directly:
+//
+//	[
+//	  {
+//	    "id": "oracle-consultation",
+//	    "cards": ["Thassa's Oracle", "Demonic Consultation"],
+//	    "prerequisites": ["Both cards in hand", "{U}{U}{B} available"],
+//	    "results": ["Win the game"]
+//	  }
+//	]
+//
+// "id" is optional; combos without one are identified by their cards.
+// Combos with no cards or no results are skipped, as are repeats of an ID
+// already read. The whole file loads in one transaction, so a failed load
+// leaves the previous combos in place.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"mana-tomb/backend/combos"
+	"mana-tomb/backend/database"
+	"mana-tomb/backend/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "path to a combo dataset JSON file")
+	batchSize := flag.Int("batch", 1000, "number of combos to send per batch")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *batchSize < 1 {
+		log.Fatal("-batch must be at least 1")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables from OS")
+	}
+
+	ctx := context.Background()
+	dbpool, err := database.Connect(ctx)
+	if err != nil {
+		log.Fatalf("Unable to create connection pool: %v", err)
+	}
+	defer dbpool.Close()
+
+	start := time.Now()
+	stats, err := syncFile(ctx, dbpool, *file, *batchSize)
+	if err != nil {
+		log.Fatalf("Combo sync failed after %d combos: %v", stats.read, err)
+	}
+	log.Printf("Done in %s: %d combos read, %d stored, %d invalid, %d repeated",
+		time.Since(start).Round(time.Second), stats.read, stats.stored, stats.invalid, stats.read-stats.stored-stats.invalid)
+}
+
+type syncStats struct {
+	read    int64
+	stored  int64
+	invalid int64
+}
+
+func syncFile(ctx context.Context, dbpool *pgxpool.Pool, path string, batchSize int) (syncStats, error) {
+	var stats syncStats
+
+	f, err := os.Open(path)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReaderSize(f, 1<<20)
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return stats, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return stats, fmt.Errorf("reading start of file: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return stats, fmt.Errorf("expected a JSON array of combos, got %v", tok)
+	}
+
+	tx, err := dbpool.Begin(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM combos`); err != nil {
+		return stats, err
+	}
+
+	batch := &pgx.Batch{}
+	for dec.More() {
+		var combo models.Combo
+		if err := dec.Decode(&combo); err != nil {
+			return stats, fmt.Errorf("decoding combo %d: %w", stats.read+1, err)
+		}
+		stats.read++
+
+		if err := combos.Clean(&combo); err != nil {
+			log.Printf("Skipping combo %d (%q): %v", stats.read, combo.ID, err)
+			stats.invalid++
+			continue
+		}
+		combos.QueueInsert(batch, combo).Exec(func(tag pgconn.CommandTag) error {
+			if tag.RowsAffected() > 0 {
+				stats.stored++
+			}
+			return nil
+		})
+		if batch.Len() >= batchSize {
+			if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+				return stats, err
+			}
+			batch = &pgx.Batch{}
+		}
+	}
+	if batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, tx.Commit(ctx)
+}
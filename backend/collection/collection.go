@@ -0,0 +1,86 @@
+// Package collection holds the rules for describing owned cards, and reads
+// and writes the collection CSV files used by common collection trackers.
+package collection
+
+import "strings"
+
+// Defaults for copies whose condition or language is not given.
+const (
+	DefaultCondition = "NM"
+	DefaultLanguage  = "en"
+)
+
+// conditions maps the spellings trackers use to the stored condition codes.
+var conditions = map[string]string{
+	"nm": "NM", "near mint": "NM", "mint": "NM", "m": "NM",
+	"lp": "LP", "lightly played": "LP", "light played": "LP", "ex": "LP", "excellent": "LP", "sp": "LP", "slightly played": "LP",
+	"mp": "MP", "moderately played": "MP", "played": "MP", "gd": "MP", "good": "MP",
+	"hp": "HP", "heavily played": "HP", "poor": "HP",
+	"dmg": "DMG", "damaged": "DMG",
+}
+
+// conditionNames are the names written to exported files.
+var conditionNames = map[string]string{
+	"NM":  "Near Mint",
+	"LP":  "Lightly Played",
+	"MP":  "Moderately Played",
+	"HP":  "Heavily Played",
+	"DMG": "Damaged",
+}
+
+// languages maps Scryfall's language codes, and the names trackers write
+// instead, to the stored code.
+var languages = map[string]string{
+	"en": "en", "english": "en",
+	"es": "es", "sp": "es", "spanish": "es",
+	"fr": "fr", "french": "fr",
+	"de": "de", "german": "de",
+	"it": "it", "italian": "it",
+	"pt": "pt", "portuguese": "pt", "portuguese (brazil)": "pt",
+	"ja": "ja", "jp": "ja", "japanese": "ja",
+	"ko": "ko", "kr": "ko", "korean": "ko",
+	"ru": "ru", "russian": "ru",
+	"zhs": "zhs", "cs": "zhs", "simplified chinese": "zhs", "chinese simplified": "zhs",
+	"zht": "zht", "ct": "zht", "traditional chinese": "zht", "chinese traditional": "zht",
+	"he": "he", "hebrew": "he",
+	"la": "la", "latin": "la",
+	"grc": "grc", "ancient greek": "grc",
+	"ar": "ar", "arabic": "ar",
+	"sa": "sa", "sanskrit": "sa",
+	"ph": "ph", "phyrexian": "ph",
+}
+
+// NormalizeCondition returns the condition code for s, which may be a code
+// or a name such as "Near Mint" or "lightly_played". An empty string means
+// Near Mint.
+func NormalizeCondition(s string) (string, bool) {
+	s = normalizeKey(s)
+	if s == "" {
+		return DefaultCondition, true
+	}
+	code, ok := conditions[s]
+	return code, ok
+}
+
+// ConditionName returns the display name of a condition code.
+func ConditionName(code string) string {
+	if name, ok := conditionNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// NormalizeLanguage returns the Scryfall language code for s, which may be
+// a code or a language name. An empty string means English.
+func NormalizeLanguage(s string) (string, bool) {
+	s = normalizeKey(s)
+	if s == "" {
+		return DefaultLanguage, true
+	}
+	code, ok := languages[s]
+	return code, ok
+}
+
+func normalizeKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(s, "_", " ")))
+}
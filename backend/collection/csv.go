@@ -0,0 +1,233 @@
+package collection
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"mana-tomb/backend/decklist"
+	"mana-tomb/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Row is one card row of a collection CSV.
+type Row struct {
+	Line            int
+	Text            string
+	Quantity        int
+	Name            string
+	SetCode         string
+	CollectorNumber string
+	ScryfallID      *uuid.UUID
+	Foil            bool
+	Condition       string
+	Language        string
+	// Err is set when the row cannot be used; the other fields are then
+	// filled in as far as they could be read.
+	Err error
+}
+
+// ErrNoNameColumn is returned for a CSV without a card name or Scryfall ID column.
+var ErrNoNameColumn = errors.New("the CSV needs a \"Name\" or \"Scryfall ID\" column")
+
+// Column names used by Deckbox, Moxfield, ManaBox, TCGplayer, Dragon Shield
+// and our own export, lower-cased.
+var columnAliases = map[string]string{
+	"count":            "count",
+	"quantity":         "count",
+	"qty":              "count",
+	"amount":           "count",
+	"name":             "name",
+	"card name":        "name",
+	"card":             "name",
+	"set code":         "set",
+	"setcode":          "set",
+	"set":              "set",
+	"edition":          "set",
+	"edition code":     "set",
+	"collector number": "number",
+	"card number":      "number",
+	"number":           "number",
+	"cn":               "number",
+	"foil":             "foil",
+	"printing":         "foil",
+	"finish":           "foil",
+	"condition":        "condition",
+	"language":         "language",
+	"lang":             "language",
+	"scryfall id":      "scryfall_id",
+	"scryfall_id":      "scryfall_id",
+}
+
+// Header is the header row written by WriteCSV.
+var Header = []string{"Count", "Name", "Set Code", "Collector Number", "Foil", "Condition", "Language", "Scryfall ID"}
+
+// ParseCSV reads a collection CSV. The first row must be a header; columns
+// are found by name, so their order does not matter and unknown columns are
+// ignored. Without a count column every row is one copy. Problems with a
+// single row are reported on that row rather than failing the whole file.
+func ParseCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the CSV is empty")
+		}
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if key, ok := columnAliases[name]; ok {
+			if _, seen := columns[key]; !seen {
+				columns[key] = i
+			}
+		}
+	}
+	_, hasName := columns["name"]
+	_, hasID := columns["scryfall_id"]
+	if !hasName && !hasID {
+		return nil, ErrNoNameColumn
+	}
+
+	var rows []Row
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Line: parseErr.Line, Err: parseErr.Err})
+				continue
+			}
+			return rows, err
+		}
+		if isBlank(record) {
+			continue
+		}
+		rows = append(rows, parseRow(line, record, columns))
+	}
+	return rows, nil
+}
+
+func parseRow(line int, record []string, columns map[string]int) Row {
+	field := func(key string) string {
+		i, ok := columns[key]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := Row{
+		Line:            line,
+		Text:            strings.Join(record, ","),
+		Quantity:        1,
+		Name:            field("name"),
+		SetCode:         strings.ToLower(field("set")),
+		CollectorNumber: field("number"),
+	}
+	// Deckbox's Edition column holds the set's full name, not its code.
+	if strings.Contains(row.SetCode, " ") {
+		row.SetCode = ""
+	}
+
+	if s := field("count"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > decklist.MaxQuantity {
+			row.Err = fmt.Errorf("count must be a whole number between 1 and %d", decklist.MaxQuantity)
+			return row
+		}
+		row.Quantity = n
+	}
+
+	if s := field("scryfall_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			row.Err = errors.New("invalid Scryfall ID")
+			return row
+		}
+		row.ScryfallID = &id
+	}
+	if row.Name == "" && row.ScryfallID == nil {
+		row.Err = errors.New("missing card name")
+		return row
+	}
+
+	foil, ok := parseFoil(field("foil"))
+	if !ok {
+		row.Err = fmt.Errorf("unknown foil value %q", field("foil"))
+		return row
+	}
+	row.Foil = foil
+
+	if row.Condition, ok = NormalizeCondition(field("condition")); !ok {
+		row.Err = fmt.Errorf("unknown condition %q", field("condition"))
+		return row
+	}
+	if row.Language, ok = NormalizeLanguage(field("language")); !ok {
+		row.Err = fmt.Errorf("unknown language %q", field("language"))
+		return row
+	}
+	return row
+}
+
+func parseFoil(s string) (foil bool, ok bool) {
+	switch strings.ToLower(s) {
+	case "foil", "etched", "true", "yes", "y", "1", "x":
+		return true, true
+	case "", "normal", "nonfoil", "non-foil", "false", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func isBlank(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteCSV writes the cards in the layout described by Header, which
+// ParseCSV and the common trackers can read back.
+func WriteCSV(w io.Writer, cards []models.CollectionCard) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Header); err != nil {
+		return err
+	}
+	for _, cc := range cards {
+		foil := ""
+		if cc.Foil {
+			foil = "foil"
+		}
+		record := []string{
+			strconv.Itoa(cc.Quantity),
+			cc.Card.Name,
+			cc.Card.SetCode,
+			cc.Card.CollectorNumber,
+			foil,
+			ConditionName(cc.Condition),
+			cc.Language,
+			cc.Card.ScryfallID.String(),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,88 @@
+// Package combos stores a dataset of known combos in the combos and
+// combo_cards tables, where decks are checked against it.
+package combos
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrNoCards   = errors.New("a combo needs at least one card")
+	ErrNoResults = errors.New("a combo needs at least one result")
+)
+
+// Clean trims a combo read from a dataset and drops repeated cards. A
+// combo without an ID is given one made from its card names, so the same
+// cards always get the same ID.
+func Clean(combo *models.Combo) error {
+	seen := make(map[string]bool, len(combo.Cards))
+	cards := make([]string, 0, len(combo.Cards))
+	for _, name := range combo.Cards {
+		name = strings.TrimSpace(name)
+		if key := Key(name); name != "" && !seen[key] {
+			seen[key] = true
+			cards = append(cards, name)
+		}
+	}
+	if len(cards) == 0 {
+		return ErrNoCards
+	}
+	combo.Cards = cards
+	combo.Prerequisites = trimAll(combo.Prerequisites)
+	combo.Results = trimAll(combo.Results)
+	if len(combo.Results) == 0 {
+		return ErrNoResults
+	}
+
+	combo.ID = strings.TrimSpace(combo.ID)
+	if combo.ID == "" {
+		keys := make([]string, len(cards))
+		for i, name := range cards {
+			keys[i] = Key(name)
+		}
+		sort.Strings(keys)
+		combo.ID = strings.Join(keys, " + ")
+	}
+	return nil
+}
+
+// Key is what card names are matched by: the lower-cased name of the front
+// face. The combo_cards index is built on the same expression.
+func Key(name string) string {
+	front, _, _ := strings.Cut(name, " // ")
+	return strings.ToLower(strings.TrimSpace(front))
+}
+
+func trimAll(list []string) []string {
+	result := make([]string, 0, len(list))
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// QueueInsert adds the insert of a cleaned combo and its cards to a batch.
+// A combo whose ID is already stored is left as it is, and the statement
+// reports no rows; otherwise it reports one row per card.
+func QueueInsert(batch *pgx.Batch, combo models.Combo) *pgx.QueuedQuery {
+	return batch.Queue(insertComboQuery, combo.ID, combo.Prerequisites, combo.Results, len(combo.Cards), combo.Cards)
+}
+
+const insertComboQuery = `
+	WITH inserted AS (
+		INSERT INTO combos (id, prerequisites, results, card_count) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id
+	)
+	INSERT INTO combo_cards (combo_id, position, card_name)
+	SELECT inserted.id, t.position, t.card_name
+	FROM inserted, unnest($5::text[]) WITH ORDINALITY AS t(card_name, position)
+`
@@ -0,0 +1,47 @@
+// Package database opens the PostgreSQL connection pool shared by the
+// server and the command-line tools.
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConnString builds a connection string from the DB_* environment variables.
+// When DB_CERT holds a CA certificate, TLS is required and verified against it.
+func ConnString() (string, error) {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"),
+	)
+
+	dbCert := os.Getenv("DB_CERT")
+	if dbCert != "" {
+		certPath := filepath.Join(os.TempDir(), "db-ca-certificate.crt")
+		err := os.WriteFile(certPath, []byte(dbCert), 0644)
+		if err != nil {
+			return "", fmt.Errorf("unable to write database certificate to temp file: %w", err)
+		}
+		connStr = fmt.Sprintf("%s sslmode=require sslrootcert=%s", connStr, certPath)
+	} else {
+		connStr = fmt.Sprintf("%s sslmode=disable", connStr)
+	}
+
+	return connStr, nil
+}
+
+// Connect creates a connection pool from the environment.
+func Connect(ctx context.Context) (*pgxpool.Pool, error) {
+	connStr, err := ConnString()
+	if err != nil {
+		return nil, err
+	}
+	return pgxpool.New(ctx, connStr)
+}
@@ -0,0 +1,13 @@
+-- 000007_add_printing_to_cards.up.sql
+
+-- Adds the set code and collector number of the cached printing.
+-- Decklist imports use them to pick a specific printing, and exports
+-- write them back out for formats such as MTG Arena.
+ALTER TABLE cards
+ADD COLUMN set_code VARCHAR(10) NOT NULL DEFAULT '',
+ADD COLUMN collector_number VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Imports resolve lines by the (front face) card name, so index it
+-- case-insensitively. Split and double-faced cards are stored as
+-- "Front // Back"; the index covers the front half.
+CREATE INDEX IF NOT EXISTS idx_cards_front_name ON cards (split_part(lower(name), ' // ', 1));
@@ -0,0 +1,23 @@
+-- 000008_create_deck_boards_table.up.sql
+
+-- User-defined boards for a deck, on top of the built-in zones
+-- (commander, main, sideboard, maybeboard and so on). deck_cards.board
+-- must name either a built-in zone or one of the deck's rows here.
+CREATE TABLE IF NOT EXISTS deck_boards (
+    deck_id UUID NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (deck_id, name)
+);
+
+-- Board names are unique per deck regardless of case.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deck_boards_lower_name ON deck_boards (deck_id, lower(name));
+
+-- Register any free-form board names already in use so existing cards
+-- stay on a known board.
+INSERT INTO deck_boards (deck_id, name)
+SELECT DISTINCT deck_id, board
+FROM deck_cards
+WHERE board NOT IN ('commander', 'companion', 'main', 'sideboard', 'maybeboard', 'considering', 'tokens')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+-- 000009_create_deck_revisions_table.up.sql
+
+-- Every change to a deck is recorded as a numbered revision. The card
+-- changes of a revision are stored as quantity deltas per card and board,
+-- so any earlier state can be rebuilt by undoing the later deltas.
+CREATE TABLE IF NOT EXISTS deck_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    deck_id UUID NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    -- The history outlives the account that made the change.
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (deck_id, revision)
+);
+
+CREATE TABLE IF NOT EXISTS deck_revision_changes (
+    revision_id BIGINT NOT NULL REFERENCES deck_revisions(id) ON DELETE CASCADE,
+    card_scryfall_id UUID NOT NULL,
+    board VARCHAR(50) NOT NULL,
+    quantity_delta INT NOT NULL,
+    PRIMARY KEY (revision_id, card_scryfall_id, board)
+);
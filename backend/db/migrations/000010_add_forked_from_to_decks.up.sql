@@ -0,0 +1,9 @@
+-- 000010_add_forked_from_to_decks.up.sql
+
+-- Records which deck a deck was forked from. Deleting the original keeps
+-- the fork but drops the reference.
+ALTER TABLE decks
+ADD COLUMN IF NOT EXISTS forked_from UUID REFERENCES decks(id) ON DELETE SET NULL;
+
+-- Used to count the forks of a deck.
+CREATE INDEX IF NOT EXISTS idx_decks_forked_from ON decks (forked_from);
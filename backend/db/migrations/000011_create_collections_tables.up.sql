@@ -0,0 +1,28 @@
+-- 000011_create_collections_tables.up.sql
+
+-- A user's card collection, split into any number of named collections
+-- (binders, boxes, a trade pile...).
+CREATE TABLE IF NOT EXISTS collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_collections_user_id ON collections (user_id);
+
+-- Owned copies of a card. Copies that differ in finish, condition or
+-- language are kept apart; identical copies share one row.
+CREATE TABLE IF NOT EXISTS collection_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    card_scryfall_id UUID NOT NULL REFERENCES cards(scryfall_id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    foil BOOLEAN NOT NULL DEFAULT FALSE,
+    condition VARCHAR(3) NOT NULL DEFAULT 'NM',
+    language VARCHAR(10) NOT NULL DEFAULT 'en',
+    UNIQUE (collection_id, card_scryfall_id, foil, condition, language)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_cards_card ON collection_cards (card_scryfall_id);
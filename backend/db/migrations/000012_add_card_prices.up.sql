@@ -0,0 +1,28 @@
+-- 000012_add_card_prices.up.sql
+
+-- Latest known market prices of each printing, as loaded by cmd/cardsync.
+-- prices_updated_on is the date the prices were taken, so an older price
+-- file never overwrites newer prices.
+ALTER TABLE cards
+ADD COLUMN IF NOT EXISTS price_usd NUMERIC(10, 2),
+ADD COLUMN IF NOT EXISTS price_usd_foil NUMERIC(10, 2),
+ADD COLUMN IF NOT EXISTS price_eur NUMERIC(10, 2),
+ADD COLUMN IF NOT EXISTS price_eur_foil NUMERIC(10, 2),
+ADD COLUMN IF NOT EXISTS price_tix NUMERIC(10, 2),
+ADD COLUMN IF NOT EXISTS prices_updated_on DATE;
+
+-- One row of prices per printing and day, kept to chart price changes.
+CREATE TABLE IF NOT EXISTS card_prices (
+    card_scryfall_id UUID NOT NULL REFERENCES cards(scryfall_id) ON DELETE CASCADE,
+    priced_on DATE NOT NULL,
+    usd NUMERIC(10, 2),
+    usd_foil NUMERIC(10, 2),
+    eur NUMERIC(10, 2),
+    eur_foil NUMERIC(10, 2),
+    tix NUMERIC(10, 2),
+    PRIMARY KEY (card_scryfall_id, priced_on)
+);
+
+-- Optional price ceiling for a deck, in US dollars.
+ALTER TABLE decks
+ADD COLUMN IF NOT EXISTS budget NUMERIC(10, 2) CHECK (budget >= 0);
@@ -0,0 +1,7 @@
+-- 000013_add_tag_overrides_to_deck_cards.up.sql
+
+-- Functional tags (ramp, draw, removal...) are worked out from each card's
+-- rules text when a deck is loaded. Owners can correct them per deck entry:
+-- the overrides map a tag to true to add it or false to remove it.
+ALTER TABLE deck_cards
+ADD COLUMN IF NOT EXISTS tag_overrides JSONB NOT NULL DEFAULT '{}'::jsonb;
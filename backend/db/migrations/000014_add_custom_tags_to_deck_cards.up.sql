@@ -0,0 +1,11 @@
+-- 000014_add_custom_tags_to_deck_cards.up.sql
+
+-- Owners organize a deck by role: free-form tags such as "Wincon" or
+-- "Sac outlet", a note per entry and their own order within a board.
+-- Entries without a sort_order follow the ordered ones, by name.
+ALTER TABLE deck_cards
+ADD COLUMN IF NOT EXISTS custom_tags VARCHAR(50)[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS sort_order INT;
+
+CREATE INDEX IF NOT EXISTS idx_deck_cards_custom_tags ON deck_cards USING GIN (custom_tags);
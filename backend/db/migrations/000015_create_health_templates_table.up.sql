@@ -0,0 +1,18 @@
+-- 000015_create_health_templates_table.up.sql
+
+-- Deck templates a user checks decks against, such as "38 lands, 10 ramp,
+-- 10 draw". The built-in templates live in the code; these are the ones
+-- users write or copy and edit for themselves. targets is a JSON list of
+-- {"category": "tag:ramp", "count": 10} objects.
+CREATE TABLE IF NOT EXISTS health_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    format VARCHAR(50) NOT NULL DEFAULT 'commander',
+    targets JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Template names are unique per user regardless of case.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_health_templates_user_lower_name ON health_templates (user_id, lower(name));
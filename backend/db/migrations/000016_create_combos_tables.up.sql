@@ -0,0 +1,22 @@
+-- 000016_create_combos_tables.up.sql
+
+-- Known combos, loaded from a dataset by cmd/combosync so decks can be
+-- checked for them without going online. Card names are kept as the
+-- dataset spells them rather than linked to cards, since a dataset may
+-- name cards the cards table does not have yet.
+CREATE TABLE IF NOT EXISTS combos (
+    id TEXT PRIMARY KEY,
+    prerequisites TEXT[] NOT NULL DEFAULT '{}',
+    results TEXT[] NOT NULL DEFAULT '{}',
+    card_count INT NOT NULL CHECK (card_count > 0)
+);
+
+CREATE TABLE IF NOT EXISTS combo_cards (
+    combo_id TEXT NOT NULL REFERENCES combos(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    card_name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (combo_id, position)
+);
+
+-- Cards are matched by the lower-cased name of their front face.
+CREATE INDEX IF NOT EXISTS idx_combo_cards_card_key ON combo_cards (lower(split_part(card_name, ' // ', 1)));
@@ -0,0 +1,261 @@
+package decklist
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/models"
+)
+
+// Encoder writes a deck in one export format.
+type Encoder interface {
+	// ContentType is sent as the response's Content-Type.
+	ContentType() string
+	// Extension is appended to the download's filename, without a dot.
+	Extension() string
+	Encode(w io.Writer, deck models.Deck) error
+}
+
+var encoders = map[string]Encoder{}
+
+// Register makes an encoder available under the given format name.
+// Registering the same name twice replaces the earlier encoder.
+func Register(format string, enc Encoder) {
+	encoders[strings.ToLower(format)] = enc
+}
+
+// Lookup returns the encoder registered for a format name.
+func Lookup(format string) (Encoder, bool) {
+	enc, ok := encoders[strings.ToLower(format)]
+	return enc, ok
+}
+
+// Formats lists the registered format names in alphabetical order.
+func Formats() []string {
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ContentDisposition builds an attachment header with a filename derived
+// from the deck name, e.g. `attachment; filename=Atraxa-Superfriends.txt`.
+func ContentDisposition(deck models.Deck, enc Encoder) string {
+	return Attachment(deck.Name, "deck", enc.Extension())
+}
+
+// Attachment builds a Content-Disposition header for a download named
+// after name, falling back to fallback when name has no usable characters.
+func Attachment(name, fallback, ext string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune(r)
+			lastDash = false
+		case !lastDash && b.Len() > 0:
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	base := strings.TrimSuffix(b.String(), "-")
+	if base == "" {
+		base = fallback
+	}
+	return mime.FormatMediaType("attachment", map[string]string{"filename": base + "." + ext})
+}
+
+func init() {
+	Register("text", textEncoder{})
+	Register("arena", arenaEncoder{})
+	Register("mtgo", mtgoEncoder{})
+	Register("csv", csvEncoder{})
+}
+
+// section is one board of a deck as it appears in an export.
+type section struct {
+	Board string
+	Title string
+	Cards []models.Card
+}
+
+// sections lists the deck's non-empty boards in display order: the
+// command zone first, then the mainboard and the rest.
+func sections(deck models.Deck) []section {
+	var out []section
+	for _, b := range deck.Boards {
+		if len(b.Cards) > 0 {
+			out = append(out, section{Board: b.Name, Title: b.Label, Cards: b.Cards})
+		}
+	}
+	return out
+}
+
+// textEncoder writes "1 Sol Ring" lines. A deck with only a mainboard is a
+// bare list; otherwise every board gets its own header, which Parse reads
+// back into the same boards. Custom boards are written under
+// CustomBoardPrefix, since Parse cannot tell their names from card names.
+type textEncoder struct{}
+
+func (textEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+func (textEncoder) Extension() string   { return "txt" }
+
+func (textEncoder) Encode(w io.Writer, deck models.Deck) error {
+	all := sections(deck)
+	for i, s := range all {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if len(all) > 1 || s.Board != boards.Main {
+			title := s.Title
+			if _, ok := boards.Builtin(s.Board); !ok {
+				title = CustomBoardPrefix + " " + s.Board
+			}
+			if _, err := fmt.Fprintln(w, title); err != nil {
+				return err
+			}
+		}
+		for _, card := range s.Cards {
+			if _, err := fmt.Fprintf(w, "%d %s\n", card.Quantity, card.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// arenaEncoder writes the MTG Arena import format, including the set code
+// and collector number when the cached printing has them. Arena only knows
+// a single sideboard, so every board besides the command zone and the deck
+// is written there. Tokens are left out.
+type arenaEncoder struct{}
+
+func (arenaEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+func (arenaEncoder) Extension() string   { return "txt" }
+
+var arenaHeaders = map[string]string{
+	boards.Commander: "Commander",
+	boards.Companion: "Companion",
+	boards.Main:      "Deck",
+}
+
+func (arenaEncoder) Encode(w io.Writer, deck models.Deck) error {
+	var groups []section
+	var side []models.Card
+	for _, s := range sections(deck) {
+		switch header, ok := arenaHeaders[s.Board]; {
+		case ok:
+			groups = append(groups, section{Title: header, Cards: s.Cards})
+		case s.Board != boards.Tokens:
+			side = append(side, s.Cards...)
+		}
+	}
+	if len(side) > 0 {
+		groups = append(groups, section{Title: "Sideboard", Cards: side})
+	}
+
+	for i, g := range groups {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, g.Title); err != nil {
+			return err
+		}
+		for _, card := range g.Cards {
+			line := fmt.Sprintf("%d %s", card.Quantity, card.Name)
+			if card.SetCode != "" {
+				line += " (" + strings.ToUpper(card.SetCode) + ")"
+				if card.CollectorNumber != "" {
+					line += " " + card.CollectorNumber
+				}
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mtgoEncoder writes the MTGO .txt format: the mainboard, a blank line, then
+// the sideboard. Like MTGO's own Commander lists, the commanders and
+// companion go in the sideboard, followed by every other board but tokens.
+type mtgoEncoder struct{}
+
+func (mtgoEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+func (mtgoEncoder) Extension() string   { return "txt" }
+
+func (mtgoEncoder) Encode(w io.Writer, deck models.Deck) error {
+	var main, side []models.Card
+	for _, s := range sections(deck) {
+		switch s.Board {
+		case boards.Main:
+			main = append(main, s.Cards...)
+		case boards.Tokens:
+		default:
+			side = append(side, s.Cards...)
+		}
+	}
+
+	for i, cards := range [][]models.Card{main, side} {
+		if i > 0 && len(side) > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		for _, card := range cards {
+			// MTGO writes split cards as "Fire/Ice".
+			name := strings.ReplaceAll(card.Name, " // ", "/")
+			if _, err := fmt.Fprintf(w, "%d %s\n", card.Quantity, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// csvEncoder writes one row per card and board with a header row.
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+func (csvEncoder) Extension() string   { return "csv" }
+
+func (csvEncoder) Encode(w io.Writer, deck models.Deck) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Count", "Name", "Board", "Set Code", "Collector Number", "Mana Cost", "Mana Value", "Type"}); err != nil {
+		return err
+	}
+	for _, s := range sections(deck) {
+		for _, card := range s.Cards {
+			row := []string{
+				strconv.Itoa(card.Quantity),
+				card.Name,
+				s.Board,
+				card.SetCode,
+				card.CollectorNumber,
+				card.ManaCost,
+				strconv.FormatFloat(float64(card.CMC), 'f', -1, 32),
+				card.TypeLine,
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
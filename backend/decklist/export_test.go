@@ -0,0 +1,83 @@
+package decklist
+
+import (
+	"bytes"
+	"testing"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/models"
+)
+
+func TestTextRoundTripWithCustomBoard(t *testing.T) {
+	deck := models.Deck{Boards: []models.Board{
+		{Name: boards.Commander, Label: "Commander", Cards: []models.Card{{Name: "Atraxa, Praetors' Voice", Quantity: 1}}},
+		{Name: boards.Main, Label: "Mainboard", Cards: []models.Card{{Name: "Sol Ring", Quantity: 1}, {Name: "Forest", Quantity: 30}}},
+		{Name: boards.Sideboard, Label: "Sideboard", Cards: []models.Card{{Name: "Swords to Plowshares", Quantity: 1}}},
+		{Name: "Wincons", Label: "Wincons", Custom: true, Cards: []models.Card{{Name: "Craterhoof Behemoth", Quantity: 1}}},
+		{Name: boards.Maybeboard, Label: "Maybeboard", Cards: []models.Card{{Name: "Cultivate", Quantity: 2}}},
+	}}
+
+	enc, ok := Lookup("text")
+	if !ok {
+		t.Fatal("text encoder is not registered")
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, deck); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	type card struct {
+		board, name string
+		quantity    int
+	}
+	var want []card
+	for _, b := range deck.Boards {
+		for _, c := range b.Cards {
+			want = append(want, card{b.Name, c.Name, c.Quantity})
+		}
+	}
+
+	entries := Parse(buf.String(), "")
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d:\n%s", len(entries), len(want), buf.String())
+	}
+	for i, e := range entries {
+		if e.Err != nil {
+			t.Errorf("line %d %q: %v", e.Line, e.Text, e.Err)
+		}
+		if got := (card{e.Board, e.Name, e.Quantity}); got != want[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestParseCustomBoardHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantBoard string
+		wantErr   bool
+	}{
+		{"custom board", "Board: Wincons\n1 Craterhoof Behemoth", "Wincons", false},
+		{"prefix is case-insensitive", "board:   Sac outlets\n1 Viscera Seer", "Sac outlets", false},
+		{"built-in board by label", "Board: Sideboard\n1 Viscera Seer", boards.Sideboard, false},
+		{"empty name", "Board:\n1 Viscera Seer", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := Parse(tt.text, "")
+			if tt.wantErr {
+				if len(entries) != 1 || entries[0].Err == nil {
+					t.Fatalf("got %+v, want a single invalid entry", entries)
+				}
+				return
+			}
+			if len(entries) != 1 || entries[0].Err != nil {
+				t.Fatalf("got %+v, want a single card", entries)
+			}
+			if entries[0].Board != tt.wantBoard {
+				t.Errorf("got board %q, want %q", entries[0].Board, tt.wantBoard)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package decklist reads and writes plain-text decklists in the formats
+// other deckbuilding sites and MTG clients exchange.
+package decklist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mana-tomb/backend/boards"
+)
+
+// MaxSideboard is the most cards a sideboard may hold.
+const MaxSideboard = 15
+
+// MaxQuantity caps a single line so a typo cannot add thousands of copies.
+const MaxQuantity = 250
+
+// Entry is one card line of a decklist.
+type Entry struct {
+	Line            int    `json:"line"`
+	Text            string `json:"text"`
+	Quantity        int    `json:"quantity"`
+	Name            string `json:"name"`
+	SetCode         string `json:"set,omitempty"`
+	CollectorNumber string `json:"collector_number,omitempty"`
+	Board           string `json:"board"`
+	// Err is set when the line looks like a card but cannot be used.
+	Err error `json:"-"`
+}
+
+// sectionHeaders maps the section names used by Arena, MTGO, Moxfield and
+// friends to our board names. An empty board means "skip this section".
+var sectionHeaders = map[string]string{
+	"deck":        boards.Main,
+	"main":        boards.Main,
+	"mainboard":   boards.Main,
+	"main deck":   boards.Main,
+	"sideboard":   boards.Sideboard,
+	"side":        boards.Sideboard,
+	"commander":   boards.Commander,
+	"commanders":  boards.Commander,
+	"companion":   boards.Companion,
+	"maybeboard":  boards.Maybeboard,
+	"maybe":       boards.Maybeboard,
+	"considering": boards.Considering,
+	"tokens":      boards.Tokens,
+	"about":       "",
+}
+
+var (
+	// "4 Lightning Bolt", "1x Sol Ring (C21) 263", "1 Sol Ring [C21]", "2 Island *F*"
+	quantityLine = regexp.MustCompile(`^(\d+)\s*[xX]?\s+(.+)$`)
+	printing     = regexp.MustCompile(`\s+[\(\[]([A-Za-z0-9]{2,6})[\)\]](?:\s+([A-Za-z0-9★\-]+))?$`)
+	markers      = regexp.MustCompile(`(?:\s+\*[A-Za-z]+\*)+$`)
+)
+
+// CustomBoardPrefix starts the header of a board the owner made, such as
+// "Board: Wincons", so it cannot be mistaken for a card line.
+const CustomBoardPrefix = "Board:"
+
+// Parse reads a decklist and returns its card entries in order. Blank
+// lines, comments and section headers are consumed rather than returned.
+// Entries under a CustomBoardPrefix header carry that board's name; the
+// board may not exist yet.
+//
+// When a list has no section headers at all, a blank line after the first
+// cards starts the sideboard, which is how MTGO and many forums write it.
+// Commander decks have no sideboard, so for them, and whenever more than
+// MaxSideboard cards follow the blank line, the cards stay on the
+// mainboard. format is the deck's format, or "" when it is not known.
+// Reading stops at a line over 1 MB; an entry with Err set marks where.
+func Parse(text, format string) []Entry {
+	var entries []Entry
+	board := boards.Main
+	skipping := false
+	sawHeader := false
+	sawCards := false
+	// blankSideboard indexes the entries the blank-line rule moved.
+	var blankSideboard []int
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Text()
+		line := strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff"))
+
+		if line == "" {
+			if !sawHeader && sawCards && board == boards.Main {
+				board = boards.Sideboard
+			}
+			continue
+		}
+
+		// "// Sideboard" is a header in some exports; any other "//" or "#"
+		// line is a comment.
+		if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			comment := strings.TrimSpace(strings.TrimLeft(line, "/#"))
+			if b, ok := header(comment); ok {
+				board, skipping, sawHeader = b, b == "", true
+			}
+			continue
+		}
+		if b, ok := header(line); ok {
+			board, skipping, sawHeader = b, b == "", true
+			continue
+		}
+		if rest, ok := cutPrefixFold(line, CustomBoardPrefix); ok {
+			sawHeader = true
+			if b, ok := header(rest); ok {
+				board, skipping = b, b == ""
+				continue
+			}
+			name, err := boards.ValidateCustomName(rest)
+			if err != nil {
+				entries = append(entries, Entry{Line: lineNo, Text: raw, Board: board,
+					Err: fmt.Errorf("%v; the cards under it were skipped", err)})
+				skipping = true
+				continue
+			}
+			board, skipping = name, false
+			continue
+		}
+		if skipping {
+			continue
+		}
+
+		entryBoard := board
+		rest, explicit := cutPrefixFold(line, "SB:")
+		if explicit {
+			entryBoard = boards.Sideboard
+			line = strings.TrimSpace(rest)
+		}
+
+		entry := parseLine(line)
+		entry.Line = lineNo
+		entry.Text = raw
+		entry.Board = entryBoard
+		if !sawHeader && board == boards.Sideboard && !explicit {
+			blankSideboard = append(blankSideboard, len(entries))
+		}
+		entries = append(entries, entry)
+		sawCards = true
+	}
+
+	// Undo the blank-line rule when the list turned out to have headers
+	// after all, or the cards after the blank line cannot be a sideboard.
+	if len(blankSideboard) > 0 {
+		count := 0
+		for _, i := range blankSideboard {
+			count += entries[i].Quantity
+		}
+		if sawHeader || strings.EqualFold(format, "commander") || count > MaxSideboard {
+			for _, i := range blankSideboard {
+				entries[i].Board = boards.Main
+			}
+		}
+	}
+
+	// The scanner stops at a line too long for its buffer. Report it as an
+	// invalid line so the import does not look complete.
+	if err := scanner.Err(); err != nil {
+		message := err.Error()
+		if errors.Is(err, bufio.ErrTooLong) {
+			message = "line is too long"
+		}
+		entries = append(entries, Entry{
+			Line:  lineNo + 1,
+			Board: board,
+			Err:   fmt.Errorf("%s; the rest of the list was not read", message),
+		})
+	}
+
+	return entries
+}
+
+func header(line string) (string, bool) {
+	key := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(line), ":"))
+	board, ok := sectionHeaders[key]
+	return board, ok
+}
+
+func parseLine(line string) Entry {
+	entry := Entry{Quantity: 1}
+	name := line
+
+	if m := quantityLine.FindStringSubmatch(line); m != nil {
+		qty, err := strconv.Atoi(m[1])
+		if err != nil || qty < 1 || qty > MaxQuantity {
+			entry.Err = fmt.Errorf("quantity must be between 1 and %d", MaxQuantity)
+		}
+		entry.Quantity = qty
+		name = m[2]
+	}
+
+	name = markers.ReplaceAllString(name, "")
+	if m := printing.FindStringSubmatch(name); m != nil {
+		entry.SetCode = strings.ToLower(m[1])
+		entry.CollectorNumber = m[2]
+		name = name[:len(name)-len(m[0])]
+	}
+
+	entry.Name = strings.TrimSpace(name)
+	if entry.Name == "" && entry.Err == nil {
+		entry.Err = fmt.Errorf("missing card name")
+	}
+	return entry
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
@@ -0,0 +1,87 @@
+package decklist
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"mana-tomb/backend/boards"
+)
+
+// boardCounts adds up the copies parsed onto each board.
+func boardCounts(entries []Entry) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Board] += e.Quantity
+	}
+	return counts
+}
+
+func TestParseBlankLineSideboard(t *testing.T) {
+	var ninetyNine strings.Builder
+	for i := 0; i < 33; i++ {
+		fmt.Fprintf(&ninetyNine, "3 Card %d\n", i)
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		format string
+		want   map[string]int
+	}{
+		{
+			name: "MTGO sideboard",
+			text: "4 Lightning Bolt\n56 Mountain\n\n3 Pyroblast\n2 Smash to Smithereens",
+			want: map[string]int{boards.Main: 60, boards.Sideboard: 5},
+		},
+		{
+			name:   "commander list keeps everything on main",
+			text:   "4 Lightning Bolt\n56 Mountain\n\n3 Pyroblast",
+			format: "commander",
+			want:   map[string]int{boards.Main: 63},
+		},
+		{
+			name: "blank line after the commander",
+			text: "1 Atraxa, Praetors' Voice\n\n" + ninetyNine.String(),
+			want: map[string]int{boards.Main: 100},
+		},
+		{
+			name: "more than a sideboard after the blank line",
+			text: "40 Forest\n\n16 Llanowar Elves",
+			want: map[string]int{boards.Main: 56},
+		},
+		{
+			name: "exactly a full sideboard",
+			text: "60 Forest\n\n15 Naturalize",
+			want: map[string]int{boards.Main: 60, boards.Sideboard: 15},
+		},
+		{
+			name: "SB: lines stay on the sideboard",
+			text: "1 Atraxa, Praetors' Voice\n\n" + ninetyNine.String() + "SB: 1 Pyroblast",
+			want: map[string]int{boards.Main: 100, boards.Sideboard: 1},
+		},
+		{
+			name: "headers turn the rule off",
+			text: "Deck\n4 Lightning Bolt\n\n20 Mountain\n\nSideboard\n2 Pyroblast",
+			want: map[string]int{boards.Main: 24, boards.Sideboard: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := boardCounts(Parse(tt.text, tt.format))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLineTooLong(t *testing.T) {
+	entries := Parse("1 Sol Ring\n"+strings.Repeat("x", 2<<20)+"\n1 Island", "")
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if last := entries[1]; last.Err == nil || last.Line != 2 {
+		t.Errorf("got %+v, want an error on line 2", last)
+	}
+}
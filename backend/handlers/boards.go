@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	errUnknownBoard = errors.New("unknown board")
+	errManagedBoard = errors.New("this board is managed through its own endpoint")
+)
+
+// loadCustomBoards returns the names of a deck's user-defined boards in display order.
+func loadCustomBoards(ctx context.Context, q querier, deckID uuid.UUID) ([]string, error) {
+	rows, err := q.Query(ctx, `SELECT name FROM deck_boards WHERE deck_id = $1 ORDER BY position, created_at`, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// ensureBoard returns the name cards go on for a board named in an import,
+// creating the custom board when the deck does not have it. An existing
+// board whose name differs only in case is reused.
+func ensureBoard(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, name string) (string, error) {
+	if _, ok := boards.Builtin(name); ok {
+		return name, nil
+	}
+	query := `
+		WITH inserted AS (
+			INSERT INTO deck_boards (deck_id, name, position)
+			SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM deck_boards WHERE deck_id = $1
+			ON CONFLICT DO NOTHING
+			RETURNING name
+		)
+		SELECT name FROM inserted
+		UNION ALL
+		SELECT name FROM deck_boards WHERE deck_id = $1 AND lower(name) = lower($2)
+		LIMIT 1
+	`
+	err := tx.QueryRow(ctx, query, deckID, name).Scan(&name)
+	return name, err
+}
+
+// checkBoard makes sure cards may be placed on the named board directly:
+// it must be a built-in board that is not managed, or one of the deck's
+// custom boards.
+func checkBoard(ctx context.Context, q querier, deckID uuid.UUID, name string) error {
+	if zone, ok := boards.Builtin(name); ok {
+		if zone.Managed {
+			return errManagedBoard
+		}
+		return nil
+	}
+
+	var exists bool
+	err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM deck_boards WHERE deck_id = $1 AND name = $2)`, deckID, name).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errUnknownBoard
+	}
+	return nil
+}
+
+// respondBoardError writes the response for an error from checkBoard.
+func respondBoardError(c *gin.Context, board string, err error) {
+	switch {
+	case errors.Is(err, errUnknownBoard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown board '" + board + "'"})
+	case errors.Is(err, errManagedBoard):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cards cannot be placed on the '" + board + "' board directly; use the commanders endpoint"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check board"})
+	}
+}
+
+// GetBoards lists the built-in boards and the deck's custom boards.
+// It expects DeckAccess(DeckRead) to have run first.
+func GetBoards(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		custom, err := loadCustomBoards(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve boards"})
+			return
+		}
+
+		type boardInfo struct {
+			Name    string `json:"name"`
+			Label   string `json:"label"`
+			Custom  bool   `json:"custom"`
+			Managed bool   `json:"managed"`
+		}
+		list := make([]boardInfo, 0)
+		for _, zone := range boards.Builtins() {
+			list = append(list, boardInfo{Name: zone.Name, Label: zone.Label, Managed: zone.Managed})
+		}
+		for _, name := range custom {
+			list = append(list, boardInfo{Name: name, Label: name, Custom: true})
+		}
+
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// CreateBoard adds a user-defined board to a deck.
+// It expects DeckAccess(DeckWrite) to have run first.
+func CreateBoard(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var requestBody struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+
+		name, err := boards.ValidateCustomName(requestBody.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board name: " + err.Error()})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create board"})
+			return
+		}
+
+		query := `
+			INSERT INTO deck_boards (deck_id, name, position)
+			SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM deck_boards WHERE deck_id = $1
+		`
+		if _, err := tx.Exec(context.Background(), query, deck.ID, name); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				c.JSON(http.StatusConflict, gin.H{"error": "The deck already has a board with that name"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create board"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionCreateBoard, "Created board "+strconv.Quote(name)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"name": name, "label": name, "custom": true})
+	}
+}
+
+// DeleteBoard removes an empty user-defined board from a deck.
+// It expects DeckAccess(DeckWrite) to have run first.
+func DeleteBoard(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		name := c.Param("board")
+
+		if _, ok := boards.Builtin(name); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Built-in boards cannot be deleted"})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete board"})
+			return
+		}
+
+		var cardCount int
+		countQuery := `SELECT COUNT(*) FROM deck_cards WHERE deck_id = $1 AND board = $2`
+		if err := tx.QueryRow(context.Background(), countQuery, deck.ID, name).Scan(&cardCount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete board"})
+			return
+		}
+		if cardCount > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Move or remove the cards on this board before deleting it"})
+			return
+		}
+
+		cmdTag, err := tx.Exec(context.Background(), `DELETE FROM deck_boards WHERE deck_id = $1 AND name = $2`, deck.ID, name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete board"})
+			return
+		}
+		if cmdTag.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionDeleteBoard, "Deleted board "+strconv.Quote(name)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Board deleted successfully"})
+	}
+}
+
+// SetBoardOrder puts the cards of a board in the owner's order. The body
+// lists card IDs first to last; cards left out follow them by name.
+// It expects DeckAccess(DeckWrite) to have run first.
+func SetBoardOrder(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		board := c.Param("board")
+
+		var requestBody struct {
+			CardIDs []uuid.UUID `json:"card_ids" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		seen := make(map[uuid.UUID]bool, len(requestBody.CardIDs))
+		for _, id := range requestBody.CardIDs {
+			if seen[id] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Card " + id.String() + " is listed twice"})
+				return
+			}
+			seen[id] = true
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sort board"})
+			return
+		}
+
+		clearQuery := `UPDATE deck_cards SET sort_order = NULL WHERE deck_id = $1 AND board = $2`
+		if _, err := tx.Exec(context.Background(), clearQuery, deck.ID, board); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sort board"})
+			return
+		}
+		orderQuery := `
+			UPDATE deck_cards dc
+			SET sort_order = o.position
+			FROM unnest($3::uuid[]) WITH ORDINALITY AS o(card_id, position)
+			WHERE dc.deck_id = $1 AND dc.board = $2 AND dc.card_scryfall_id = o.card_id
+		`
+		cmdTag, err := tx.Exec(context.Background(), orderQuery, deck.ID, board, requestBody.CardIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sort board"})
+			return
+		}
+		if int(cmdTag.RowsAffected()) != len(requestBody.CardIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Every card listed must be on board '" + board + "'"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionSortBoard, "Sorted board "+strconv.Quote(board)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"board": board, "card_ids": requestBody.CardIDs})
+	}
+}
+
+// MoveCards moves some copies of a card from one board to another in a
+// single transaction. It expects DeckAccess(DeckWrite) to have run first.
+func MoveCards(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		cardID, err := uuid.Parse(c.Param("cardId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+			return
+		}
+
+		var requestBody struct {
+			From     string `json:"from" binding:"required"`
+			To       string `json:"to" binding:"required"`
+			Quantity int    `json:"quantity"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		if requestBody.Quantity == 0 {
+			requestBody.Quantity = 1
+		}
+		if requestBody.Quantity < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity must be positive"})
+			return
+		}
+		if requestBody.From == requestBody.To {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination boards must differ"})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		for _, board := range []string{requestBody.From, requestBody.To} {
+			if err := checkBoard(context.Background(), tx, deck.ID, board); err != nil {
+				respondBoardError(c, board, err)
+				return
+			}
+		}
+
+		// Starting the revision locks the deck, so concurrent edits cannot
+		// move the same copies twice.
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move card"})
+			return
+		}
+
+		var available int
+		var overrides map[string]bool
+		var customTags []string
+		var note string
+		lockQuery := `
+			SELECT quantity, tag_overrides, custom_tags, note FROM deck_cards
+			WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3
+			FOR UPDATE
+		`
+		err = tx.QueryRow(context.Background(), lockQuery, deck.ID, cardID, requestBody.From).Scan(&available, &overrides, &customTags, &note)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found on board '" + requestBody.From + "'"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move card"})
+			return
+		}
+		if available < requestBody.Quantity {
+			c.JSON(http.StatusConflict, gin.H{"error": "Not enough copies on the source board"})
+			return
+		}
+
+		if available == requestBody.Quantity {
+			_, err = tx.Exec(context.Background(), `DELETE FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`,
+				deck.ID, cardID, requestBody.From)
+		} else {
+			_, err = tx.Exec(context.Background(), `UPDATE deck_cards SET quantity = quantity - $4 WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`,
+				deck.ID, cardID, requestBody.From, requestBody.Quantity)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move card"})
+			return
+		}
+
+		// Copies moved to a board the card is not on yet keep their tags and
+		// note; copies joining an existing entry take on its own.
+		var destination int
+		addQuery := `
+			INSERT INTO deck_cards (deck_id, card_scryfall_id, board, quantity, tag_overrides, custom_tags, note)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (deck_id, card_scryfall_id, board) DO UPDATE
+			SET quantity = deck_cards.quantity + EXCLUDED.quantity
+			RETURNING quantity
+		`
+		err = tx.QueryRow(context.Background(), addQuery, deck.ID, cardID, requestBody.To, requestBody.Quantity,
+			overrides, customTags, note).Scan(&destination)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move card"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionMoveCards, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from": deckCardEntry{CardID: cardID, Board: requestBody.From, Quantity: available - requestBody.Quantity},
+			"to":   deckCardEntry{CardID: cardID, Board: requestBody.To, Quantity: destination},
+		})
+	}
+}
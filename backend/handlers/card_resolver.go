@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+const (
+	matchMatched    = "matched"
+	matchAmbiguous  = "ambiguous"
+	matchUnresolved = "unresolved"
+	matchInvalid    = "invalid"
+)
+
+// cardResolution is the outcome of looking a card name up in the cards table.
+type cardResolution struct {
+	Status     string
+	CardID     uuid.UUID
+	CardName   string
+	Candidates []string
+}
+
+type printingRow struct {
+	id              uuid.UUID
+	name            string
+	setCode         string
+	collectorNumber string
+}
+
+// printingIndex holds the cached printings of a set of card names, keyed by
+// frontKey, so many lines can be resolved with a single query.
+type printingIndex map[string][]printingRow
+
+// frontKey is what a name is looked up by: its lower-cased front face.
+func frontKey(name string) string {
+	front, _, _ := strings.Cut(name, "/")
+	return strings.ToLower(strings.TrimSpace(front))
+}
+
+// loadPrintings fetches every printing of the named cards in one query.
+func loadPrintings(ctx context.Context, q querier, names []string) (printingIndex, error) {
+	index := make(printingIndex)
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		key := frontKey(name)
+		if _, ok := index[key]; !ok {
+			index[key] = nil
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return index, nil
+	}
+
+	query := `
+		SELECT scryfall_id, name, set_code, collector_number
+		FROM cards
+		WHERE split_part(lower(name), ' // ', 1) = ANY($1)
+		ORDER BY name, set_code, collector_number
+	`
+	rows, err := q.Query(ctx, query, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p printingRow
+		if err := rows.Scan(&p.id, &p.name, &p.setCode, &p.collectorNumber); err != nil {
+			return nil, err
+		}
+		// The same key the query matched on.
+		front, _, _ := strings.Cut(p.name, " // ")
+		key := strings.ToLower(front)
+		index[key] = append(index[key], p)
+	}
+	return index, rows.Err()
+}
+
+// resolve finds the cached card a decklist line refers to among the
+// loaded printings.
+//
+// Names are compared case-insensitively, and the front face alone is enough
+// for split and double-faced cards ("Fire" or "Fire/Ice" both find
+// "Fire // Ice"). Several printings of the same card are not ambiguous: the
+// set code and collector number pick one when given, otherwise any printing
+// will do. A line is only ambiguous when it matches differently named cards.
+func (index printingIndex) resolve(name, setCode, collectorNumber string) cardResolution {
+	printings := index[frontKey(name)]
+	if len(printings) == 0 {
+		return cardResolution{Status: matchUnresolved}
+	}
+
+	// Prefer an exact full-name match over a front-face match.
+	var exact []printingRow
+	for _, p := range printings {
+		if strings.EqualFold(p.name, strings.TrimSpace(name)) {
+			exact = append(exact, p)
+		}
+	}
+	if len(exact) > 0 {
+		printings = exact
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range printings {
+		if !seen[p.name] {
+			seen[p.name] = true
+			names = append(names, p.name)
+		}
+	}
+	if len(names) > 1 {
+		return cardResolution{Status: matchAmbiguous, Candidates: names}
+	}
+
+	chosen := pickPrinting(printings, setCode, collectorNumber)
+	return cardResolution{Status: matchMatched, CardID: chosen.id, CardName: chosen.name}
+}
+
+func pickPrinting(printings []printingRow, setCode, collectorNumber string) printingRow {
+	if setCode != "" {
+		var inSet *printingRow
+		for i, p := range printings {
+			if !strings.EqualFold(p.setCode, setCode) {
+				continue
+			}
+			if collectorNumber == "" || strings.EqualFold(p.collectorNumber, collectorNumber) {
+				return p
+			}
+			if inSet == nil {
+				inSet = &printings[i]
+			}
+		}
+		if inSet != nil {
+			return *inSet
+		}
+	}
+	return printings[0]
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mana-tomb/backend/cardsource"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchCards proxies a card search to the configured card source, so the
+// frontend never has to call Scryfall itself.
+func SearchCards(source cardsource.CardSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+			return
+		}
+
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page number"})
+			return
+		}
+
+		result, err := source.Search(c.Request.Context(), query, page)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to search cards"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"mana-tomb/backend/collection"
+	"mana-tomb/backend/decklist"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxCSVSize caps uploaded collection files. Tens of thousands of rows fit
+// comfortably.
+const maxCSVSize = 20 << 20
+
+// ImportCollectionCSV adds the rows of a collection CSV to a collection.
+// The file is sent either as the "file" field of a multipart form or as the
+// raw request body. With ?replace=true the collection is emptied first.
+// Every row is listed in the report, so unmatched rows can be fixed and
+// imported again.
+func ImportCollectionCSV(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		body, err := readCSVUpload(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the CSV: " + err.Error()})
+			return
+		}
+		rows, err := collection.ParseCSV(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV: " + err.Error()})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		if c.Query("replace") == "true" {
+			if _, err := tx.Exec(context.Background(), `DELETE FROM collection_cards WHERE collection_id = $1`, col.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear collection"})
+				return
+			}
+		}
+
+		report, err := importCollectionRows(context.Background(), tx, col.ID, rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import cards"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ExportCollectionCSV downloads a collection as a CSV that ImportCollectionCSV
+// and the common collection trackers can read.
+func ExportCollectionCSV(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		cards, err := loadCollectionCards(context.Background(), dbpool, col.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection cards"})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := collection.WriteCSV(&buf, cards); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export collection"})
+			return
+		}
+
+		c.Header("Content-Disposition", decklist.Attachment(col.Name, "collection", "csv"))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	}
+}
+
+// readCSVUpload returns the uploaded file from a multipart form, or the raw
+// request body otherwise.
+func readCSVUpload(c *gin.Context) (io.Reader, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCSVSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("the request body is empty")
+	}
+	return bytes.NewReader(data), nil
+}
+
+// importCollectionRows resolves each row against the cards table and adds
+// the matched ones to the collection inside the caller's transaction. A
+// Scryfall ID pins the exact printing; otherwise the name, set code and
+// collector number are resolved the same way as decklist lines. Exports run
+// to thousands of rows, so the cards are looked up and the rows written in
+// a few round trips rather than one per row.
+func importCollectionRows(ctx context.Context, tx pgx.Tx, collectionID uuid.UUID, rows []collection.Row) (models.ImportReport, error) {
+	report := models.ImportReport{Lines: make([]models.ImportLine, 0, len(rows))}
+
+	var ids []uuid.UUID
+	var names []string
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+		if row.ScryfallID != nil {
+			ids = append(ids, *row.ScryfallID)
+		}
+		if row.Name != "" {
+			names = append(names, row.Name)
+		}
+	}
+	byID, err := loadCardNames(ctx, tx, ids)
+	if err != nil {
+		return report, err
+	}
+	printings, err := loadPrintings(ctx, tx, names)
+	if err != nil {
+		return report, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		line := models.ImportLine{
+			Line:     row.Line,
+			Text:     row.Text,
+			Quantity: row.Quantity,
+		}
+
+		if row.Err != nil {
+			line.Status = matchInvalid
+			line.Message = row.Err.Error()
+			report.Invalid++
+			report.Lines = append(report.Lines, line)
+			continue
+		}
+
+		res := cardResolution{Status: matchUnresolved}
+		if row.ScryfallID != nil {
+			if name, ok := byID[*row.ScryfallID]; ok {
+				res = cardResolution{Status: matchMatched, CardID: *row.ScryfallID, CardName: name}
+			}
+		}
+		if res.Status != matchMatched && row.Name != "" {
+			res = printings.resolve(row.Name, row.SetCode, row.CollectorNumber)
+		}
+		line.Status = res.Status
+
+		switch res.Status {
+		case matchMatched:
+			batch.Queue(addCollectionCardQuery, collectionID, res.CardID, row.Quantity, row.Foil, row.Condition, row.Language)
+			cardID := res.CardID
+			line.CardID = &cardID
+			line.CardName = res.CardName
+			report.Matched++
+			report.CardsAdded += row.Quantity
+		case matchAmbiguous:
+			line.Candidates = res.Candidates
+			line.Message = "Several cards match this name"
+			report.Ambiguous++
+		default:
+			if row.Name != "" {
+				line.Message = "No card named \"" + row.Name + "\" was found"
+			} else {
+				line.Message = "No card with this Scryfall ID was found"
+			}
+			report.Unresolved++
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	if batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// loadCardNames returns the names of the cached cards among ids.
+func loadCardNames(ctx context.Context, q querier, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	rows, err := q.Query(ctx, `SELECT scryfall_id, name FROM cards WHERE scryfall_id = ANY($1)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"mana-tomb/backend/cardsource"
+	"mana-tomb/backend/collection"
+	"mana-tomb/backend/decklist"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// currentUserID returns the logged-in user's ID, writing a 401 response
+// itself when the session does not hold a valid one.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// loadOwnedCollection fetches the collection named by the :collectionId
+// route parameter. Collections are private, so one that belongs to someone
+// else is reported as not found. It writes the error response itself.
+func loadOwnedCollection(c *gin.Context, q querier) (models.Collection, bool) {
+	var col models.Collection
+	userID, ok := currentUserID(c)
+	if !ok {
+		return col, false
+	}
+	collectionID, err := uuid.Parse(c.Param("collectionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return col, false
+	}
+
+	query := `
+		SELECT id, user_id, name, created_at, updated_at,
+		       (SELECT COALESCE(SUM(quantity), 0) FROM collection_cards WHERE collection_id = collections.id)
+		FROM collections
+		WHERE id = $1 AND user_id = $2
+	`
+	err = q.QueryRow(context.Background(), query, collectionID, userID).Scan(
+		&col.ID, &col.UserID, &col.Name, &col.CreatedAt, &col.UpdatedAt, &col.CardCount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+			return col, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+		return col, false
+	}
+	return col, true
+}
+
+// GetCollections lists the current user's collections with their card counts.
+func GetCollections(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+
+		query := `
+			SELECT col.id, col.user_id, col.name, col.created_at, col.updated_at, COALESCE(SUM(cc.quantity), 0)
+			FROM collections col
+			LEFT JOIN collection_cards cc ON cc.collection_id = col.id
+			WHERE col.user_id = $1
+			GROUP BY col.id
+			ORDER BY col.name
+		`
+		rows, err := dbpool.Query(context.Background(), query, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collections"})
+			return
+		}
+		defer rows.Close()
+
+		collections := make([]models.Collection, 0)
+		for rows.Next() {
+			var col models.Collection
+			if err := rows.Scan(&col.ID, &col.UserID, &col.Name, &col.CreatedAt, &col.UpdatedAt, &col.CardCount); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan collection row"})
+				return
+			}
+			collections = append(collections, col)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collections"})
+			return
+		}
+
+		c.JSON(http.StatusOK, collections)
+	}
+}
+
+// CreateCollection creates an empty collection for the current user.
+func CreateCollection(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+
+		query := `
+			INSERT INTO collections (user_id, name)
+			VALUES ($1, $2)
+			RETURNING id, user_id, name, created_at, updated_at
+		`
+		var col models.Collection
+		err := dbpool.QueryRow(context.Background(), query, userID, requestBody.Name).Scan(
+			&col.ID, &col.UserID, &col.Name, &col.CreatedAt, &col.UpdatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, col)
+	}
+}
+
+// GetCollection returns one of the current user's collections with its cards.
+func GetCollection(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		cards, err := loadCollectionCards(context.Background(), dbpool, col.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection cards"})
+			return
+		}
+		col.Cards = cards
+
+		c.JSON(http.StatusOK, col)
+	}
+}
+
+// UpdateCollection renames a collection.
+func UpdateCollection(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		var requestBody struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		query := `UPDATE collections SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING name, updated_at`
+		if err := dbpool.QueryRow(context.Background(), query, requestBody.Name, col.ID).Scan(&col.Name, &col.UpdatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection"})
+			return
+		}
+
+		c.JSON(http.StatusOK, col)
+	}
+}
+
+// DeleteCollection deletes a collection and every card in it.
+func DeleteCollection(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		if _, err := dbpool.Exec(context.Background(), `DELETE FROM collections WHERE id = $1`, col.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+	}
+}
+
+// AddCollectionCard adds copies of a card to a collection. As with decks the
+// client sends only the Scryfall ID and the card data comes from the card
+// source. Copies matching an existing stack are added to it.
+func AddCollectionCard(dbpool *pgxpool.Pool, source cardsource.CardSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		var requestBody struct {
+			ScryfallID uuid.UUID `json:"scryfall_id" binding:"required"`
+			Quantity   int       `json:"quantity"`
+			Foil       bool      `json:"foil"`
+			Condition  string    `json:"condition"`
+			Language   string    `json:"language"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		if requestBody.Quantity == 0 {
+			requestBody.Quantity = 1
+		}
+		if requestBody.Quantity < 0 || requestBody.Quantity > decklist.MaxQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantity must be between 1 and %d", decklist.MaxQuantity)})
+			return
+		}
+		condition, ok := collection.NormalizeCondition(requestBody.Condition)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown condition '" + requestBody.Condition + "'"})
+			return
+		}
+		language, ok := collection.NormalizeLanguage(requestBody.Language)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language"})
+			return
+		}
+
+		card, ok := fetchCard(c, source, requestBody.ScryfallID)
+		if !ok {
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		if err := cardsource.Upsert(context.Background(), tx, card); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cache card data"})
+			return
+		}
+
+		entry := models.CollectionCard{Card: card, Foil: requestBody.Foil, Condition: condition, Language: language}
+		err = tx.QueryRow(context.Background(), addCollectionCardQuery,
+			col.ID, card.ScryfallID, requestBody.Quantity, requestBody.Foil, condition, language).Scan(&entry.ID, &entry.Quantity)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add card to collection"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// UpdateCollectionCard changes the quantity, finish, condition or language
+// of a stack of cards. Fields left out of the request keep their values; a
+// quantity of zero deletes the stack.
+func UpdateCollectionCard(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+		entryID, err := uuid.Parse(c.Param("entryId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+			return
+		}
+
+		var requestBody struct {
+			Quantity  *int    `json:"quantity"`
+			Foil      *bool   `json:"foil"`
+			Condition *string `json:"condition"`
+			Language  *string `json:"language"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		var entry models.CollectionCard
+		var cardID uuid.UUID
+		lockQuery := `
+			SELECT id, card_scryfall_id, quantity, foil, condition, language
+			FROM collection_cards
+			WHERE id = $1 AND collection_id = $2
+			FOR UPDATE
+		`
+		err = tx.QueryRow(context.Background(), lockQuery, entryID, col.ID).Scan(
+			&entry.ID, &cardID, &entry.Quantity, &entry.Foil, &entry.Condition, &entry.Language)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found in collection"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection card"})
+			return
+		}
+
+		if requestBody.Quantity != nil {
+			if *requestBody.Quantity < 0 || *requestBody.Quantity > decklist.MaxQuantity {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantity must be between 0 and %d", decklist.MaxQuantity)})
+				return
+			}
+			entry.Quantity = *requestBody.Quantity
+		}
+		if requestBody.Foil != nil {
+			entry.Foil = *requestBody.Foil
+		}
+		if requestBody.Condition != nil {
+			condition, ok := collection.NormalizeCondition(*requestBody.Condition)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown condition '" + *requestBody.Condition + "'"})
+				return
+			}
+			entry.Condition = condition
+		}
+		if requestBody.Language != nil {
+			language, ok := collection.NormalizeLanguage(*requestBody.Language)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language"})
+				return
+			}
+			entry.Language = language
+		}
+
+		if err := saveCollectionCard(context.Background(), tx, col.ID, cardID, &entry); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection card"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		if entry.Quantity == 0 {
+			c.JSON(http.StatusOK, gin.H{"message": "Card removed from collection"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"id":        entry.ID,
+			"card_id":   cardID,
+			"quantity":  entry.Quantity,
+			"foil":      entry.Foil,
+			"condition": entry.Condition,
+			"language":  entry.Language,
+		})
+	}
+}
+
+// DeleteCollectionCard removes a stack of cards from a collection.
+func DeleteCollectionCard(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+		entryID, err := uuid.Parse(c.Param("entryId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+			return
+		}
+
+		cmdTag, err := dbpool.Exec(context.Background(), `DELETE FROM collection_cards WHERE id = $1 AND collection_id = $2`, entryID, col.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove card"})
+			return
+		}
+		if cmdTag.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Card not found in collection"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Card removed from collection"})
+	}
+}
+
+// ImportCollection adds the cards from a plain-text list to a collection.
+// The list uses the same format as decklist imports; section headers are
+// ignored. Finish, condition and language apply to every line.
+func ImportCollection(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		col, ok := loadOwnedCollection(c, dbpool)
+		if !ok {
+			return
+		}
+
+		var requestBody struct {
+			Text      string `json:"text" binding:"required"`
+			Foil      bool   `json:"foil"`
+			Condition string `json:"condition"`
+			Language  string `json:"language"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		condition, ok := collection.NormalizeCondition(requestBody.Condition)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown condition '" + requestBody.Condition + "'"})
+			return
+		}
+		language, ok := collection.NormalizeLanguage(requestBody.Language)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language"})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		entries := decklist.Parse(requestBody.Text, "")
+		for i := range entries {
+			entries[i].Board = ""
+		}
+		report, err := resolveEntries(context.Background(), tx, entries, func(cardID uuid.UUID, entry decklist.Entry) error {
+			_, err := tx.Exec(context.Background(), addCollectionCardQuery, col.ID, cardID, entry.Quantity, requestBody.Foil, condition, language)
+			return err
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import cards"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// addCollectionCardQuery adds copies to a collection, merging them into an
+// identical stack when there is one. It returns the stack's ID and quantity.
+const addCollectionCardQuery = `
+	INSERT INTO collection_cards (collection_id, card_scryfall_id, quantity, foil, condition, language)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (collection_id, card_scryfall_id, foil, condition, language) DO UPDATE
+	SET quantity = collection_cards.quantity + EXCLUDED.quantity
+	RETURNING id, quantity
+`
+
+// saveCollectionCard writes an edited stack back. A quantity of zero deletes
+// it, and a stack edited to match another one is merged into it, in which
+// case entry takes the surviving stack's ID and quantity.
+func saveCollectionCard(ctx context.Context, tx pgx.Tx, collectionID, cardID uuid.UUID, entry *models.CollectionCard) error {
+	if entry.Quantity == 0 {
+		_, err := tx.Exec(ctx, `DELETE FROM collection_cards WHERE id = $1`, entry.ID)
+		return err
+	}
+
+	var otherID uuid.UUID
+	matchQuery := `
+		SELECT id FROM collection_cards
+		WHERE collection_id = $1 AND card_scryfall_id = $2 AND foil = $3 AND condition = $4 AND language = $5 AND id <> $6
+		FOR UPDATE
+	`
+	err := tx.QueryRow(ctx, matchQuery, collectionID, cardID, entry.Foil, entry.Condition, entry.Language, entry.ID).Scan(&otherID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		updateQuery := `UPDATE collection_cards SET quantity = $2, foil = $3, condition = $4, language = $5 WHERE id = $1`
+		_, err = tx.Exec(ctx, updateQuery, entry.ID, entry.Quantity, entry.Foil, entry.Condition, entry.Language)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM collection_cards WHERE id = $1`, entry.ID); err != nil {
+		return err
+	}
+	mergeQuery := `UPDATE collection_cards SET quantity = quantity + $2 WHERE id = $1 RETURNING id, quantity`
+	return tx.QueryRow(ctx, mergeQuery, otherID, entry.Quantity).Scan(&entry.ID, &entry.Quantity)
+}
+
+// loadCollectionCards returns every stack in a collection with its card data.
+func loadCollectionCards(ctx context.Context, q querier, collectionID uuid.UUID) ([]models.CollectionCard, error) {
+	query := `
+		SELECT cc.id, c.scryfall_id, c.name, c.image_uris, c.mana_cost, c.cmc, c.type_line, c.oracle_text, c.colors, c.color_identity,
+		       c.set_code, c.collector_number, cc.quantity, cc.foil, cc.condition, cc.language
+		FROM collection_cards cc
+		JOIN cards c ON c.scryfall_id = cc.card_scryfall_id
+		WHERE cc.collection_id = $1
+		ORDER BY c.name, c.set_code, c.collector_number, cc.foil, cc.condition, cc.language
+	`
+	rows, err := q.Query(ctx, query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := make([]models.CollectionCard, 0)
+	for rows.Next() {
+		var cc models.CollectionCard
+		card := &cc.Card
+		if err := rows.Scan(&cc.ID, &card.ScryfallID, &card.Name, &card.ImageURIs, &card.ManaCost, &card.CMC, &card.TypeLine, &card.OracleText, &card.Colors, &card.ColorIdentity,
+			&card.SetCode, &card.CollectorNumber, &cc.Quantity, &cc.Foil, &cc.Condition, &cc.Language); err != nil {
+			return nil, err
+		}
+		cards = append(cards, cc)
+	}
+	return cards, rows.Err()
+}
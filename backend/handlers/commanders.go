@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/cardsource"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// commanderZone is the request body for choosing a deck's commanders.
+type commanderZone struct {
+	Commanders []uuid.UUID `json:"commanders"`
+	Companion  *uuid.UUID  `json:"companion"`
+}
+
+// SetDeckCommanders replaces a deck's commander zone and companion.
+// It expects DeckAccess(DeckWrite) to have run first.
+func SetDeckCommanders(dbpool *pgxpool.Pool, source cardsource.CardSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var requestBody commanderZone
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+
+		commanders, companion, ok := resolveCommanderZone(c, source, requestBody)
+		if !ok {
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update commanders"})
+			return
+		}
+
+		if err := writeCommanderZone(context.Background(), tx, deck.ID, commanders, companion); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update commanders"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionSetCommanders, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		if err := loadDeckCards(context.Background(), dbpool, &deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		c.JSON(http.StatusOK, deck)
+	}
+}
+
+// resolveCommanderZone fetches the requested cards from the card source and
+// checks that they form a legal commander zone. It writes the error
+// response itself and reports false when the request cannot go ahead.
+// An empty commander list is allowed and clears the zone.
+func resolveCommanderZone(c *gin.Context, source cardsource.CardSource, zone commanderZone) ([]models.Card, *models.Card, bool) {
+	commanders := make([]models.Card, 0, len(zone.Commanders))
+	for _, id := range zone.Commanders {
+		card, ok := fetchCard(c, source, id)
+		if !ok {
+			return nil, nil, false
+		}
+		card.Quantity = 1
+		commanders = append(commanders, card)
+	}
+	if len(commanders) > 0 {
+		if err := validation.CheckCommanders(commanders); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid commanders: " + err.Error()})
+			return nil, nil, false
+		}
+	}
+
+	var companion *models.Card
+	if zone.Companion != nil {
+		card, ok := fetchCard(c, source, *zone.Companion)
+		if !ok {
+			return nil, nil, false
+		}
+		if err := validation.CheckCompanion(card); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid companion: " + err.Error()})
+			return nil, nil, false
+		}
+		card.Quantity = 1
+		companion = &card
+	}
+
+	return commanders, companion, true
+}
+
+// fetchCard looks a card up in the card source, writing the error response
+// itself when that fails.
+func fetchCard(c *gin.Context, source cardsource.CardSource, id uuid.UUID) (models.Card, bool) {
+	card, err := source.Card(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, cardsource.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Card not found: " + id.String()})
+			return card, false
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch card data"})
+		return card, false
+	}
+	return card, true
+}
+
+// writeCommanderZone replaces the commander and companion boards of a deck.
+// A card promoted to commander is taken out of the mainboard so it is not
+// counted twice.
+func writeCommanderZone(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, commanders []models.Card, companion *models.Card) error {
+	clearQuery := `DELETE FROM deck_cards WHERE deck_id = $1 AND board IN ($2, $3)`
+	if _, err := tx.Exec(ctx, clearQuery, deckID, boards.Commander, boards.Companion); err != nil {
+		return err
+	}
+
+	insertQuery := `INSERT INTO deck_cards (deck_id, card_scryfall_id, board, quantity) VALUES ($1, $2, $3, 1)`
+	removeFromMainQuery := `DELETE FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`
+	for _, commander := range commanders {
+		if err := cardsource.Upsert(ctx, tx, commander); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, insertQuery, deckID, commander.ScryfallID, boards.Commander); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, removeFromMainQuery, deckID, commander.ScryfallID, boards.Main); err != nil {
+			return err
+		}
+	}
+	if companion != nil {
+		if err := cardsource.Upsert(ctx, tx, *companion); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, insertQuery, deckID, companion.ScryfallID, boards.Companion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
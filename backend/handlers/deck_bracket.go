@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/bracket"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/tags"
+
+	"github.com/google/uuid"
+)
+
+// isCommanderFormat reports whether a deck is played under Commander rules.
+// Decks without a format are Commander decks.
+func isCommanderFormat(deck models.Deck) bool {
+	return deck.Format == "" || deck.Format == "commander"
+}
+
+// estimateBracket sets the bracket estimate of a Commander deck whose
+// cards are loaded.
+func estimateBracket(ctx context.Context, q querier, deck *models.Deck) error {
+	if !isCommanderFormat(*deck) {
+		return nil
+	}
+	found, err := findCombos(ctx, q, []uuid.UUID{deck.ID})
+	if err != nil {
+		return err
+	}
+	cards := append(append([]models.Card(nil), deck.Cards(boards.Commander)...), deck.Cards(boards.Main)...)
+	estimate := bracket.Estimate(cards, bracketCombos(found[deck.ID]))
+	deck.Bracket = &estimate
+	return nil
+}
+
+// bracketCombos returns the two-card combos built into the estimator
+// together with the complete two-card combos found in a deck's stored
+// combos.
+func bracketCombos(found models.DeckCombos) []bracket.Combo {
+	combos := bracket.KnownCombos()
+	for _, match := range found.Complete {
+		if len(match.Cards) == 2 {
+			combos = append(combos, bracket.Combo{Cards: match.Cards, Result: strings.Join(match.Results, ", ")})
+		}
+	}
+	return combos
+}
+
+// loadDeckBrackets sets the bracket estimate of every Commander deck in a
+// listing with a single query for their cards. The cards carry the same
+// tags as in loadDeckEntries, so the estimate matches GetDeckByID.
+func loadDeckBrackets(ctx context.Context, q querier, decks []models.Deck) error {
+	var ids []uuid.UUID
+	for _, deck := range decks {
+		if isCommanderFormat(deck) {
+			ids = append(ids, deck.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT dc.deck_id, c.name, c.mana_cost, c.type_line, c.oracle_text, dc.quantity, dc.tag_overrides, dc.custom_tags
+		FROM deck_cards dc
+		JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+		WHERE dc.deck_id = ANY($1) AND dc.board IN ($2, $3)
+	`
+	rows, err := q.Query(ctx, query, ids, boards.Commander, boards.Main)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byDeck := make(map[uuid.UUID][]models.Card)
+	for rows.Next() {
+		var deckID uuid.UUID
+		var card models.Card
+		if err := rows.Scan(&deckID, &card.Name, &card.ManaCost, &card.TypeLine, &card.OracleText, &card.Quantity, &card.TagOverrides, &card.CustomTags); err != nil {
+			return err
+		}
+		card.Tags = tags.Apply(tags.Classify(card), card.TagOverrides)
+		byDeck[deckID] = append(byDeck[deckID], card)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	found, err := findCombos(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range decks {
+		if isCommanderFormat(decks[i]) {
+			estimate := bracket.Estimate(byDeck[decks[i].ID], bracketCombos(found[decks[i].ID]))
+			decks[i].Bracket = &estimate
+		}
+	}
+	return nil
+}
@@ -2,38 +2,55 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
-	"mana-tomb/backend/models"
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/cardsource"
+	"mana-tomb/backend/decklist"
+	"mana-tomb/backend/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// AddCardToDeck now accepts a 'board' parameter in the request body.
-func AddCardToDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+// AddCardToDeck adds one copy of a card to a board of the deck. The client
+// sends only the card's Scryfall ID; the card data itself is fetched from
+// the card source so we never store client-supplied card details.
+// It expects DeckAccess(DeckWrite) to have run first.
+func AddCardToDeck(dbpool *pgxpool.Pool, source cardsource.CardSource) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deckIDStr := c.Param("deckId")
-		deckID, err := uuid.Parse(deckIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID format"})
-			return
-		}
+		deckID := middleware.CurrentDeck(c).ID
 
 		var requestBody struct {
-			Card  models.Card `json:"card"`
-			Board string      `json:"board"`
+			ScryfallID uuid.UUID `json:"scryfall_id" binding:"required"`
+			Board      string    `json:"board"`
 		}
 		if err := c.ShouldBindJSON(&requestBody); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
 			return
 		}
 
-		card := requestBody.Card
 		board := requestBody.Board
 		if board == "" {
-			board = "main" // Default to main board
+			board = boards.Main // Default to main board
+		}
+		if err := checkBoard(context.Background(), dbpool, deckID, board); err != nil {
+			respondBoardError(c, board, err)
+			return
+		}
+
+		card, err := source.Card(c.Request.Context(), requestBody.ScryfallID)
+		if err != nil {
+			if errors.Is(err, cardsource.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+				return
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch card data"})
+			return
 		}
 
 		tx, err := dbpool.Begin(context.Background())
@@ -43,14 +60,13 @@ func AddCardToDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 		}
 		defer tx.Rollback(context.Background())
 
-		cardCacheQuery := `
-			INSERT INTO cards (scryfall_id, name, image_uris, mana_cost, cmc, type_line, oracle_text, colors, color_identity)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-			ON CONFLICT (scryfall_id) DO NOTHING
-		`
-		_, err = tx.Exec(context.Background(), cardCacheQuery,
-			card.ScryfallID, card.Name, card.ImageURIs, card.ManaCost, card.CMC, card.TypeLine, card.OracleText, card.Colors, card.ColorIdentity)
+		rev, err := beginRevision(context.Background(), tx, deckID)
 		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add card to deck"})
+			return
+		}
+
+		if err := cardsource.Upsert(context.Background(), tx, card); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cache card data"})
 			return
 		}
@@ -68,6 +84,11 @@ func AddCardToDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionAddCard, "Added "+card.Name+" to "+board); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
 		if err := tx.Commit(context.Background()); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
 			return
@@ -77,46 +98,184 @@ func AddCardToDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	}
 }
 
-// RemoveCardFromDeck handles decrementing a card's quantity or removing it entirely.
-func RemoveCardFromDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+// deckCardEntry is a deck_cards row as returned by the card-editing endpoints.
+type deckCardEntry struct {
+	CardID   uuid.UUID `json:"card_id"`
+	Board    string    `json:"board"`
+	Quantity int       `json:"quantity"`
+}
+
+// SetCardQuantity sets the exact number of copies of a card on one board.
+// A quantity of zero removes the card from that board. The card must
+// already be in the card cache, which is the case for anything that has
+// been in a deck. It expects DeckAccess(DeckWrite) to have run first.
+func SetCardQuantity(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deckIDStr := c.Param("deckId")
-		cardIDStr := c.Param("cardId")
-		deckID, err := uuid.Parse(deckIDStr)
+		deckID := middleware.CurrentDeck(c).ID
+		cardID, err := uuid.Parse(c.Param("cardId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+			return
+		}
+
+		var requestBody struct {
+			Board    string `json:"board"`
+			Quantity *int   `json:"quantity" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		quantity := *requestBody.Quantity
+		if quantity < 0 || quantity > decklist.MaxQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantity must be between 0 and %d", decklist.MaxQuantity)})
+			return
+		}
+		board := requestBody.Board
+		if board == "" {
+			board = boards.Main
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		if err := checkBoard(context.Background(), tx, deckID, board); err != nil {
+			respondBoardError(c, board, err)
+			return
+		}
+
+		rev, err := beginRevision(context.Background(), tx, deckID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card quantity"})
+			return
+		}
+
+		entry := deckCardEntry{CardID: cardID, Board: board, Quantity: quantity}
+		if quantity == 0 {
+			deleteQuery := `DELETE FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`
+			cmdTag, err := tx.Exec(context.Background(), deleteQuery, deckID, cardID, board)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card quantity"})
+				return
+			}
+			if cmdTag.RowsAffected() == 0 {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found on board '" + board + "'"})
+				return
+			}
+		} else {
+			// deck_cards has no foreign key to cards, so an unknown card
+			// would be stored where no deck listing ever shows it.
+			var known bool
+			if err := tx.QueryRow(context.Background(), `SELECT EXISTS (SELECT 1 FROM cards WHERE scryfall_id = $1)`, cardID).Scan(&known); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card quantity"})
+				return
+			}
+			if !known {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+				return
+			}
+
+			setQuery := `
+				INSERT INTO deck_cards (deck_id, card_scryfall_id, board, quantity)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (deck_id, card_scryfall_id, board) DO UPDATE
+				SET quantity = EXCLUDED.quantity
+			`
+			if _, err := tx.Exec(context.Background(), setQuery, deckID, cardID, board, quantity); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card quantity"})
+				return
+			}
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionSetQuantity, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
 			return
 		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// RemoveCardFromDeck removes one copy of a card from a board, deleting the
+// entry when it was the last copy. The board comes from the ?board= query
+// parameter and defaults to the mainboard. It expects DeckAccess(DeckWrite)
+// to have run first.
+func RemoveCardFromDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := middleware.CurrentDeck(c).ID
+		cardIDStr := c.Param("cardId")
 		cardID, err := uuid.Parse(cardIDStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
 			return
 		}
+		board := c.DefaultQuery("board", boards.Main)
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		if err := checkBoard(context.Background(), tx, deckID, board); err != nil {
+			respondBoardError(c, board, err)
+			return
+		}
+
+		// Starting the revision locks the deck, so a concurrent removal cannot
+		// read the same quantity.
+		rev, err := beginRevision(context.Background(), tx, deckID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove card"})
+			return
+		}
 
-		// First, check the current quantity.
 		var quantity int
-		checkQuery := `SELECT quantity FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2`
-		err = dbpool.QueryRow(context.Background(), checkQuery, deckID, cardID).Scan(&quantity)
+		checkQuery := `SELECT quantity FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3 FOR UPDATE`
+		err = tx.QueryRow(context.Background(), checkQuery, deckID, cardID, board).Scan(&quantity)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Card not found in deck"})
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found on board '" + board + "'"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove card"})
 			return
 		}
 
 		if quantity > 1 {
 			// If more than one, decrement the quantity.
-			updateQuery := `UPDATE deck_cards SET quantity = quantity - 1 WHERE deck_id = $1 AND card_scryfall_id = $2`
-			_, err = dbpool.Exec(context.Background(), updateQuery, deckID, cardID)
+			updateQuery := `UPDATE deck_cards SET quantity = quantity - 1 WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`
+			_, err = tx.Exec(context.Background(), updateQuery, deckID, cardID, board)
 		} else {
 			// If only one, delete the row.
-			deleteQuery := `DELETE FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2`
-			_, err = dbpool.Exec(context.Background(), deleteQuery, deckID, cardID)
+			deleteQuery := `DELETE FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`
+			_, err = tx.Exec(context.Background(), deleteQuery, deckID, cardID, board)
 		}
-
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove card"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Card removed successfully"})
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionRemoveCard, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, deckCardEntry{CardID: cardID, Board: board, Quantity: quantity - 1})
 	}
 }
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetDeckCombos lists the stored combos the deck's commander zone and
+// mainboard contain, and those they are one card away from, naming the
+// card missing. Combos are loaded by cmd/combosync; with none loaded both
+// lists are empty. It expects DeckAccess(DeckRead) to have run first.
+func GetDeckCombos(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		found, err := findCombos(context.Background(), dbpool, []uuid.UUID{deck.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find combos"})
+			return
+		}
+
+		c.JSON(http.StatusOK, found[deck.ID])
+	}
+}
+
+// findCombos matches the commander zone and mainboard of each deck against
+// the combos table. Every deck gets an entry, with empty lists when it has
+// no combos. Cards are matched by the lower-cased name of their front face,
+// like combos.Key.
+func findCombos(ctx context.Context, q querier, deckIDs []uuid.UUID) (map[uuid.UUID]models.DeckCombos, error) {
+	found := make(map[uuid.UUID]models.DeckCombos, len(deckIDs))
+	for _, id := range deckIDs {
+		found[id] = models.DeckCombos{DeckID: id, Complete: make([]models.ComboMatch, 0), OneCardAway: make([]models.ComboMatch, 0)}
+	}
+
+	// Only combos sharing a card with a deck are looked at; of those, the
+	// ones the deck lacks at most one card of are kept.
+	query := `
+		WITH deck AS (
+			SELECT DISTINCT dc.deck_id, lower(split_part(c.name, ' // ', 1)) AS card_key
+			FROM deck_cards dc
+			JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+			WHERE dc.deck_id = ANY($1) AND dc.board IN ($2, $3)
+		), candidates AS (
+			SELECT DISTINCT d.deck_id, cc.combo_id
+			FROM deck d
+			JOIN combo_cards cc ON lower(split_part(cc.card_name, ' // ', 1)) = d.card_key
+		)
+		SELECT ca.deck_id, co.id, co.prerequisites, co.results,
+			array_agg(cc.card_name ORDER BY cc.position),
+			array_agg(cc.card_name ORDER BY cc.position) FILTER (WHERE d.card_key IS NULL)
+		FROM candidates ca
+		JOIN combos co ON co.id = ca.combo_id
+		JOIN combo_cards cc ON cc.combo_id = co.id
+		LEFT JOIN deck d ON d.deck_id = ca.deck_id AND d.card_key = lower(split_part(cc.card_name, ' // ', 1))
+		GROUP BY ca.deck_id, co.id
+		HAVING count(*) - count(d.card_key) <= 1
+		ORDER BY co.card_count, co.id
+	`
+	rows, err := q.Query(ctx, query, deckIDs, boards.Commander, boards.Main)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deckID uuid.UUID
+		var match models.ComboMatch
+		var missing []string
+		if err := rows.Scan(&deckID, &match.ID, &match.Prerequisites, &match.Results, &match.Cards, &missing); err != nil {
+			return nil, err
+		}
+		result := found[deckID]
+		if len(missing) == 0 {
+			result.Complete = append(result.Complete, match)
+		} else {
+			match.Missing = missing[0]
+			result.OneCardAway = append(result.OneCardAway, match)
+		}
+		found[deckID] = result
+	}
+	return found, rows.Err()
+}
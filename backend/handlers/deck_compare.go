@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/manacost"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CompareDecks compares two decks given as ?a= and ?b=. Each deck must be
+// readable by the caller under the usual rules: owned, or public.
+func CompareDecks(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var decks [2]models.Deck
+		var entries [2][]deckEntry
+		for i, param := range []string{"a", "b"} {
+			deckID, err := uuid.Parse(c.Query(param))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'a' and 'b' must be deck IDs"})
+				return
+			}
+
+			deck, err := middleware.LoadDeck(context.Background(), dbpool, deckID, c.GetString("userID"), middleware.DeckRead)
+			if err != nil {
+				middleware.AbortWithDeckError(c, err)
+				return
+			}
+			decks[i] = deck
+
+			entries[i], err = loadDeckEntries(context.Background(), dbpool, deckID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, compareDecks(decks[0], entries[0], decks[1], entries[1]))
+	}
+}
+
+func compareDecks(deckA models.Deck, entriesA []deckEntry, deckB models.Deck, entriesB []deckEntry) models.DeckComparison {
+	result := models.DeckComparison{
+		A:       summarizeDeck(deckA, entriesA),
+		B:       summarizeDeck(deckB, entriesB),
+		OnlyInA: make([]models.BoardCard, 0),
+		OnlyInB: make([]models.BoardCard, 0),
+		Shared:  make([]models.SharedCard, 0),
+	}
+
+	quantitiesA, quantitiesB := quantitiesByName(entriesA), quantitiesByName(entriesB)
+	for name, byBoard := range quantitiesA {
+		if _, ok := quantitiesB[name]; !ok {
+			result.OnlyInA = append(result.OnlyInA, boardCards(name, byBoard)...)
+		}
+	}
+	for name, byBoard := range quantitiesB {
+		if _, ok := quantitiesA[name]; !ok {
+			result.OnlyInB = append(result.OnlyInB, boardCards(name, byBoard)...)
+		}
+	}
+	for name, byBoardA := range quantitiesA {
+		byBoardB, ok := quantitiesB[name]
+		if !ok {
+			continue
+		}
+		shared := models.SharedCard{Name: name}
+		for _, board := range unionBoards(byBoardA, byBoardB) {
+			shared.Boards = append(shared.Boards, models.BoardQuantity{
+				Board:      board,
+				QuantityA:  byBoardA[board],
+				QuantityB:  byBoardB[board],
+				Difference: byBoardB[board] - byBoardA[board],
+			})
+		}
+		result.Shared = append(result.Shared, shared)
+	}
+
+	sortBoardCards(result.OnlyInA)
+	sortBoardCards(result.OnlyInB)
+	sort.Slice(result.Shared, func(i, j int) bool { return result.Shared[i].Name < result.Shared[j].Name })
+
+	result.Delta = models.SummaryDelta{
+		CardCount:         result.B.CardCount - result.A.CardCount,
+		AverageCMC:        round2(result.B.AverageCMC - result.A.AverageCMC),
+		ColorDistribution: make(map[string]int),
+	}
+	for color := range result.A.ColorDistribution {
+		result.Delta.ColorDistribution[color] = result.B.ColorDistribution[color] - result.A.ColorDistribution[color]
+	}
+	for color := range result.B.ColorDistribution {
+		result.Delta.ColorDistribution[color] = result.B.ColorDistribution[color] - result.A.ColorDistribution[color]
+	}
+
+	return result
+}
+
+// summarizeDeck counts the commander zone and mainboard. The average mana
+// value and color distribution leave out lands, as deckbuilders usually do;
+// colorless spells are counted under "C".
+func summarizeDeck(deck models.Deck, entries []deckEntry) models.DeckSummary {
+	summary := models.DeckSummary{
+		ID:                deck.ID,
+		Name:              deck.Name,
+		UserID:            deck.UserID,
+		ColorDistribution: make(map[string]int),
+	}
+
+	var totalCMC float64
+	spells := 0
+	for _, e := range entries {
+		if e.Board != boards.Commander && e.Board != boards.Main {
+			continue
+		}
+		summary.CardCount += e.Card.Quantity
+		if strings.Contains(strings.ToLower(e.Card.TypeLine), "land") {
+			continue
+		}
+		// Cards without a mana cost keep the colors of their color indicator.
+		manaValue, colors := float64(e.Card.CMC), e.Card.Colors
+		if cost, err := manacost.Parse(e.Card.ManaCost); err == nil && e.Card.ManaCost != "" {
+			manaValue, colors = cost.ManaValue(), cost.Colors()
+		}
+		totalCMC += manaValue * float64(e.Card.Quantity)
+		spells += e.Card.Quantity
+		if len(colors) == 0 {
+			summary.ColorDistribution["C"] += e.Card.Quantity
+		}
+		for _, color := range colors {
+			summary.ColorDistribution[color] += e.Card.Quantity
+		}
+	}
+	if spells > 0 {
+		summary.AverageCMC = round2(totalCMC / float64(spells))
+	}
+	return summary
+}
+
+// quantitiesByName totals a deck's copies of each card per board, merging
+// printings of the same card.
+func quantitiesByName(entries []deckEntry) map[string]map[string]int {
+	out := make(map[string]map[string]int)
+	for _, e := range entries {
+		if out[e.Card.Name] == nil {
+			out[e.Card.Name] = make(map[string]int)
+		}
+		out[e.Card.Name][e.Board] += e.Card.Quantity
+	}
+	return out
+}
+
+func boardCards(name string, byBoard map[string]int) []models.BoardCard {
+	cards := make([]models.BoardCard, 0, len(byBoard))
+	for board, quantity := range byBoard {
+		cards = append(cards, models.BoardCard{Name: name, Board: board, Quantity: quantity})
+	}
+	return cards
+}
+
+// unionBoards returns the boards present in either map in display order.
+func unionBoards(a, b map[string]int) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range []map[string]int{a, b} {
+		for board := range m {
+			if !seen[board] {
+				seen[board] = true
+				out = append(out, board)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		pi, pj := boards.Position(out[i]), boards.Position(out[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+func sortBoardCards(cards []models.BoardCard) {
+	sort.Slice(cards, func(i, j int) bool {
+		pi, pj := boards.Position(cards[i].Board), boards.Position(cards[j].Board)
+		if pi != pj {
+			return pi < pj
+		}
+		if cards[i].Board != cards[j].Board {
+			return cards[i].Board < cards[j].Board
+		}
+		return cards[i].Name < cards[j].Name
+	})
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"mana-tomb/backend/decklist"
+	"mana-tomb/backend/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExportDeck writes a deck as a downloadable decklist. The format query
+// parameter picks a registered decklist encoder and defaults to "text".
+// It expects DeckAccess(DeckRead) to have run first.
+func ExportDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		format := c.DefaultQuery("format", "text")
+		enc, ok := decklist.Lookup(format)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown export format. Supported formats: " + strings.Join(decklist.Formats(), ", ")})
+			return
+		}
+
+		if err := loadDeckCards(context.Background(), dbpool, &deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		// Encode into a buffer first so a failure can still be reported as JSON.
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export deck"})
+			return
+		}
+
+		c.Header("Content-Disposition", decklist.ContentDisposition(deck, enc))
+		c.Data(http.StatusOK, enc.ContentType(), buf.Bytes())
+	}
+}
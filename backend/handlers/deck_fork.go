@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ForkDeck copies a deck, its custom boards and all of its cards into a new
+// private deck owned by the current user. The copy remembers which deck it
+// came from. Anyone who can read a deck may fork it, so this expects
+// DeckAccess(DeckRead) to have run first.
+func ForkDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		source := middleware.CurrentDeck(c)
+
+		// The body is optional; without a name the fork keeps the original's.
+		var requestBody struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		name := requestBody.Name
+		if name == "" {
+			name = source.Name
+		}
+
+		userIDStr, _ := c.Get("userID")
+		userID, err := uuid.Parse(userIDStr.(string))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		query := `
+			INSERT INTO decks (name, description, format, user_id, forked_from, budget)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, name, description, format, user_id, is_public, created_at, updated_at, forked_from, budget
+		`
+		var fork models.Deck
+		err = tx.QueryRow(context.Background(), query, name, source.Description, source.Format, userID, source.ID, source.Budget).Scan(
+			&fork.ID, &fork.Name, &fork.Description, &fork.Format,
+			&fork.UserID, &fork.IsPublic, &fork.CreatedAt, &fork.UpdatedAt, &fork.ForkedFrom, &fork.Budget,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create deck: " + err.Error()})
+			return
+		}
+
+		rev, err := beginRevision(context.Background(), tx, fork.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fork deck"})
+			return
+		}
+
+		copyBoardsQuery := `
+			INSERT INTO deck_boards (deck_id, name, position)
+			SELECT $1, name, position FROM deck_boards WHERE deck_id = $2
+		`
+		if _, err := tx.Exec(context.Background(), copyBoardsQuery, fork.ID, source.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy boards"})
+			return
+		}
+
+		copyCardsQuery := `
+			INSERT INTO deck_cards (deck_id, card_scryfall_id, board, quantity, tag_overrides, custom_tags, note, sort_order)
+			SELECT $1, card_scryfall_id, board, quantity, tag_overrides, custom_tags, note, sort_order
+			FROM deck_cards WHERE deck_id = $2
+		`
+		if _, err := tx.Exec(context.Background(), copyCardsQuery, fork.ID, source.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy cards"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, &userID, actionFork, "Forked from "+source.Name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		if err := loadDeckCards(context.Background(), dbpool, &fork); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, fork)
+	}
+}
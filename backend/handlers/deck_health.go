@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/health"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetDeckHealth compares the deck's commander zone and mainboard with a
+// template and reports where it falls short or runs over. ?template= picks
+// a built-in template or one saved by the reader or the deck's owner;
+// without it the built-in template for the deck's format is used.
+// It expects DeckAccess(DeckRead) to have run first.
+func GetDeckHealth(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var template models.HealthTemplate
+		if id := c.Query("template"); id != "" {
+			owners := []uuid.UUID{deck.UserID}
+			if reader, err := uuid.Parse(c.GetString("userID")); err == nil {
+				owners = append(owners, reader)
+			}
+			var err error
+			template, err = loadHealthTemplate(context.Background(), dbpool, id, owners)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve template"})
+				return
+			}
+		} else {
+			var ok bool
+			if template, ok = health.DefaultFor(deck.Format); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "There is no built-in template for format '" + deck.Format + "'; pick one with ?template="})
+				return
+			}
+		}
+
+		// The template's categories were checked when it was saved, but the
+		// category rules may have changed since.
+		matchers := make(map[string]func(models.Card) bool, len(template.Targets))
+		for _, target := range template.Targets {
+			match, err := parseCategory(target.Category)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Template target: " + err.Error()})
+				return
+			}
+			matchers[target.Category] = match
+		}
+
+		if err := loadDeckCards(context.Background(), dbpool, &deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+		cards := append(append([]models.Card(nil), deck.Cards(boards.Commander)...), deck.Cards(boards.Main)...)
+
+		result := health.Evaluate(template, func(category string) int {
+			n := 0
+			for _, card := range cards {
+				if matchers[category](card) {
+					n += card.Quantity
+				}
+			}
+			return n
+		})
+		result.DeckID = deck.ID
+		result.Format = deck.Format
+		for _, card := range cards {
+			result.Cards += card.Quantity
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+// GetDeckHistory lists a deck's revisions, newest first, with their card
+// changes. ?limit= caps the number returned and ?before= pages back from a
+// revision number. It expects DeckAccess(DeckRead) to have run first.
+func GetDeckHistory(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		limit := defaultHistoryLimit
+		if s := c.Query("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxHistoryLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxHistoryLimit)})
+				return
+			}
+			limit = n
+		}
+		before := 0
+		if s := c.Query("before"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "before must be a revision number"})
+				return
+			}
+			before = n
+		}
+
+		query := `
+			SELECT r.id, r.revision, r.user_id, u.username, r.action, r.summary, r.created_at
+			FROM deck_revisions r
+			LEFT JOIN users u ON u.id = r.user_id
+			WHERE r.deck_id = $1 AND ($2 = 0 OR r.revision < $2)
+			ORDER BY r.revision DESC
+			LIMIT $3
+		`
+		rows, err := dbpool.Query(context.Background(), query, deck.ID, before, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+			return
+		}
+		defer rows.Close()
+
+		revisions := make([]models.Revision, 0)
+		ids := make([]int64, 0)
+		for rows.Next() {
+			var id int64
+			var r models.Revision
+			if err := rows.Scan(&id, &r.Revision, &r.UserID, &r.Username, &r.Action, &r.Summary, &r.CreatedAt); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan revision row"})
+				return
+			}
+			r.Changes = make([]models.RevisionChange, 0)
+			revisions = append(revisions, r)
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+			return
+		}
+		rows.Close()
+
+		changesQuery := `
+			SELECT rc.revision_id, rc.card_scryfall_id, COALESCE(c.name, ''), rc.board, rc.quantity_delta
+			FROM deck_revision_changes rc
+			LEFT JOIN cards c ON c.scryfall_id = rc.card_scryfall_id
+			WHERE rc.revision_id = ANY($1)
+		`
+		rows, err = dbpool.Query(context.Background(), changesQuery, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+			return
+		}
+		defer rows.Close()
+
+		index := make(map[int64]int, len(ids))
+		for i, id := range ids {
+			index[id] = i
+		}
+		for rows.Next() {
+			var id int64
+			var change models.RevisionChange
+			if err := rows.Scan(&id, &change.CardID, &change.Name, &change.Board, &change.Delta); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan revision change"})
+				return
+			}
+			r := &revisions[index[id]]
+			r.Changes = append(r.Changes, change)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+			return
+		}
+		for i := range revisions {
+			sortChanges(revisions[i].Changes)
+		}
+
+		c.JSON(http.StatusOK, revisions)
+	}
+}
+
+// DiffDeck returns the net card changes between two revisions. ?from=
+// defaults to 0, the state before the first recorded revision, and ?to= to
+// the latest revision. When from is later than to the diff runs backwards.
+// It expects DeckAccess(DeckRead) to have run first.
+func DiffDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var latest int
+		latestQuery := `SELECT COALESCE(MAX(revision), 0) FROM deck_revisions WHERE deck_id = $1`
+		if err := dbpool.QueryRow(context.Background(), latestQuery, deck.ID).Scan(&latest); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+			return
+		}
+
+		from, ok := revisionParam(c, "from", 0, latest)
+		if !ok {
+			return
+		}
+		to, ok := revisionParam(c, "to", latest, latest)
+		if !ok {
+			return
+		}
+
+		low, high, sign := from, to, 1
+		if from > to {
+			low, high, sign = to, from, -1
+		}
+
+		query := `
+			SELECT rc.card_scryfall_id, COALESCE(c.name, ''), rc.board, SUM(rc.quantity_delta)
+			FROM deck_revision_changes rc
+			JOIN deck_revisions r ON r.id = rc.revision_id
+			LEFT JOIN cards c ON c.scryfall_id = rc.card_scryfall_id
+			WHERE r.deck_id = $1 AND r.revision > $2 AND r.revision <= $3
+			GROUP BY rc.card_scryfall_id, c.name, rc.board
+			HAVING SUM(rc.quantity_delta) <> 0
+		`
+		rows, err := dbpool.Query(context.Background(), query, deck.ID, low, high)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute diff"})
+			return
+		}
+		defer rows.Close()
+
+		diff := models.DeckDiff{
+			From:    from,
+			To:      to,
+			Added:   make([]models.RevisionChange, 0),
+			Removed: make([]models.RevisionChange, 0),
+		}
+		for rows.Next() {
+			var change models.RevisionChange
+			if err := rows.Scan(&change.CardID, &change.Name, &change.Board, &change.Delta); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan diff row"})
+				return
+			}
+			change.Delta *= sign
+			if change.Delta > 0 {
+				diff.Added = append(diff.Added, change)
+			} else {
+				diff.Removed = append(diff.Removed, change)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute diff"})
+			return
+		}
+		sortChanges(diff.Added)
+		sortChanges(diff.Removed)
+
+		c.JSON(http.StatusOK, diff)
+	}
+}
+
+// RestoreDeck rolls a deck's cards back to how they were right after the
+// given revision. Name, description and visibility are left as they are.
+// The restore is itself recorded as a new revision, so it can be undone.
+// It expects DeckAccess(DeckWrite) to have run first.
+func RestoreDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		target, err := strconv.Atoi(c.Param("revision"))
+		if err != nil || target < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore deck"})
+			return
+		}
+
+		var exists bool
+		existsQuery := `SELECT EXISTS (SELECT 1 FROM deck_revisions WHERE deck_id = $1 AND revision = $2)`
+		if err := tx.QueryRow(context.Background(), existsQuery, deck.ID, target).Scan(&exists); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore deck"})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+
+		state, err := stateAtRevision(context.Background(), tx, deck.ID, rev.before, target)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore deck"})
+			return
+		}
+		if err := replaceDeckCards(context.Background(), tx, deck.ID, rev.before, state); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore deck"})
+			return
+		}
+
+		number, err := rev.record(context.Background(), tx, revisionAuthor(c), actionRestore, fmt.Sprintf("Restored revision %d", target))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		if err := loadDeckCards(context.Background(), dbpool, &deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"revision": number, "deck": deck})
+	}
+}
+
+// stateAtRevision rebuilds a deck's cards as of a revision by undoing every
+// later delta from the current state. Working backwards keeps this correct
+// for decks whose history only starts partway through their life.
+func stateAtRevision(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, current map[cardSlot]int, target int) (map[cardSlot]int, error) {
+	state := make(map[cardSlot]int, len(current))
+	for slot, quantity := range current {
+		state[slot] = quantity
+	}
+
+	query := `
+		SELECT rc.card_scryfall_id, rc.board, SUM(rc.quantity_delta)
+		FROM deck_revision_changes rc
+		JOIN deck_revisions r ON r.id = rc.revision_id
+		WHERE r.deck_id = $1 AND r.revision > $2
+		GROUP BY rc.card_scryfall_id, rc.board
+	`
+	rows, err := tx.Query(ctx, query, deckID, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var slot cardSlot
+		var delta int
+		if err := rows.Scan(&slot.CardID, &slot.Board, &delta); err != nil {
+			return nil, err
+		}
+		state[slot] -= delta
+	}
+	return state, rows.Err()
+}
+
+// replaceDeckCards brings a deck's cards from the current state to the
+// given one, dropping slots with no copies left. Only the slots that differ
+// are written, so the tags, notes and order of the others are kept. Custom
+// boards that were deleted since are created again so the restored cards
+// stay on a known board.
+func replaceDeckCards(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, current, state map[cardSlot]int) error {
+	batch := &pgx.Batch{}
+	for slot := range current {
+		if state[slot] <= 0 {
+			batch.Queue(`DELETE FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`,
+				deckID, slot.CardID, slot.Board)
+		}
+	}
+	for slot, quantity := range state {
+		if quantity <= 0 || quantity == current[slot] {
+			continue
+		}
+		if _, ok := current[slot]; ok {
+			batch.Queue(`UPDATE deck_cards SET quantity = $4 WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`,
+				deckID, slot.CardID, slot.Board, quantity)
+			continue
+		}
+		if _, ok := boards.Builtin(slot.Board); !ok {
+			batch.Queue(`
+				INSERT INTO deck_boards (deck_id, name, position)
+				SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM deck_boards WHERE deck_id = $1
+				ON CONFLICT DO NOTHING
+			`, deckID, slot.Board)
+		}
+		batch.Queue(`INSERT INTO deck_cards (deck_id, card_scryfall_id, board, quantity) VALUES ($1, $2, $3, $4)`,
+			deckID, slot.CardID, slot.Board, quantity)
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// revisionParam reads a revision number from the query string, writing the
+// error response itself when it is not a known revision.
+func revisionParam(c *gin.Context, name string, fallback, latest int) (int, bool) {
+	s := c.Query(name)
+	if s == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > latest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a revision between 0 and %d", name, latest)})
+		return 0, false
+	}
+	return n, true
+}
+
+// sortChanges orders changes by board display order, then by card name.
+func sortChanges(changes []models.RevisionChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		pi, pj := boards.Position(changes[i].Board), boards.Position(changes[j].Board)
+		if pi != pj {
+			return pi < pj
+		}
+		if changes[i].Board != changes[j].Board {
+			return changes[i].Board < changes[j].Board
+		}
+		return changes[i].Name < changes[j].Name
+	})
+}
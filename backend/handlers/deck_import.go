@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/decklist"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ImportDecklist adds the cards from a plain-text decklist to an existing deck.
+// With "replace" set, the list replaces the deck's cards instead; cards it
+// keeps on the same board keep their tags, notes and order.
+// It expects DeckAccess(DeckWrite) to have run first.
+func ImportDecklist(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		deckID := deck.ID
+
+		var requestBody struct {
+			Text    string `json:"text" binding:"required"`
+			Replace bool   `json:"replace"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deckID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import decklist"})
+			return
+		}
+
+		entries := decklist.Parse(requestBody.Text, listFormat(deck))
+		var report models.ImportReport
+		if requestBody.Replace {
+			report, err = replaceEntries(context.Background(), tx, deckID, rev.before, entries)
+		} else {
+			report, err = importEntries(context.Background(), tx, deckID, entries)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import decklist"})
+			return
+		}
+
+		summary := "Imported a decklist"
+		if requestBody.Replace {
+			summary = "Replaced the deck with an imported decklist"
+		}
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionImport, summary); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// CreateDeckFromList creates a new deck and fills it from a plain-text decklist
+// in a single transaction.
+func CreateDeckFromList(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody struct {
+			Name        string `json:"name" binding:"required"`
+			Description string `json:"description"`
+			Format      string `json:"format"`
+			Text        string `json:"text" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userIDStr, _ := c.Get("userID")
+		userID, err := uuid.Parse(userIDStr.(string))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		query := `
+			INSERT INTO decks (name, description, format, user_id)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, name, description, format, user_id, is_public, created_at, updated_at
+		`
+		var createdDeck models.Deck
+		err = tx.QueryRow(context.Background(), query, requestBody.Name, requestBody.Description, requestBody.Format, userID).Scan(
+			&createdDeck.ID, &createdDeck.Name, &createdDeck.Description, &createdDeck.Format,
+			&createdDeck.UserID, &createdDeck.IsPublic, &createdDeck.CreatedAt, &createdDeck.UpdatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create deck: " + err.Error()})
+			return
+		}
+
+		rev, err := beginRevision(context.Background(), tx, createdDeck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create deck"})
+			return
+		}
+
+		report, err := importEntries(context.Background(), tx, createdDeck.ID, decklist.Parse(requestBody.Text, listFormat(createdDeck)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import decklist"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, &userID, actionCreate, "Created deck from a decklist"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"deck": createdDeck, "report": report})
+	}
+}
+
+// listFormat is the format decklists for a deck are parsed for.
+func listFormat(deck models.Deck) string {
+	if isCommanderFormat(deck) {
+		return "commander"
+	}
+	return deck.Format
+}
+
+// importEntries resolves each entry against the cards table and adds the
+// matched ones to the deck inside the caller's transaction. Commanders and
+// companions are checked together with those already in the deck first.
+func importEntries(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, entries []decklist.Entry) (models.ImportReport, error) {
+	addCardQuery := `
+		INSERT INTO deck_cards (deck_id, card_scryfall_id, board, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (deck_id, card_scryfall_id, board) DO UPDATE
+		SET quantity = deck_cards.quantity + EXCLUDED.quantity
+	`
+	board := importBoards(ctx, tx, deckID)
+	add := func(cardID uuid.UUID, entry decklist.Entry) error {
+		name, err := board(entry.Board)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, addCardQuery, deckID, cardID, name, entry.Quantity)
+		return err
+	}
+
+	var zone []zoneEntry
+	report, err := resolveEntries(ctx, tx, entries, func(cardID uuid.UUID, entry decklist.Entry) error {
+		if isCommandZone(entry.Board) {
+			zone = append(zone, zoneEntry{CardID: cardID, Entry: entry})
+			return nil
+		}
+		return add(cardID, entry)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	zone, err = checkImportedZone(ctx, tx, deckID, true, &report, zone)
+	if err != nil {
+		return report, err
+	}
+	for _, z := range zone {
+		if err := add(z.CardID, z.Entry); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// replaceEntries resolves each entry against the cards table and makes the
+// matched ones the deck's only cards. current is the deck's cards before
+// the import; entries that keep a card where it is keep its tags and notes.
+func replaceEntries(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, current map[cardSlot]int, entries []decklist.Entry) (models.ImportReport, error) {
+	state := make(map[cardSlot]int)
+	board := importBoards(ctx, tx, deckID)
+	var zone []zoneEntry
+	report, err := resolveEntries(ctx, tx, entries, func(cardID uuid.UUID, entry decklist.Entry) error {
+		if isCommandZone(entry.Board) {
+			zone = append(zone, zoneEntry{CardID: cardID, Entry: entry})
+			return nil
+		}
+		name, err := board(entry.Board)
+		if err != nil {
+			return err
+		}
+		state[cardSlot{CardID: cardID, Board: name}] += entry.Quantity
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	zone, err = checkImportedZone(ctx, tx, deckID, false, &report, zone)
+	if err != nil {
+		return report, err
+	}
+	for _, z := range zone {
+		state[cardSlot{CardID: z.CardID, Board: z.Entry.Board}] += z.Entry.Quantity
+	}
+	return report, replaceDeckCards(ctx, tx, deckID, current, state)
+}
+
+// importBoards returns a function mapping the boards named in an import to
+// the deck's boards, creating custom boards on first use.
+func importBoards(ctx context.Context, tx pgx.Tx, deckID uuid.UUID) func(string) (string, error) {
+	names := make(map[string]string)
+	return func(board string) (string, error) {
+		if name, ok := names[board]; ok {
+			return name, nil
+		}
+		name, err := ensureBoard(ctx, tx, deckID, board)
+		if err != nil {
+			return "", err
+		}
+		names[board] = name
+		return name, nil
+	}
+}
+
+// zoneEntry is an imported commander or companion, held back until the
+// command zone as a whole has been checked.
+type zoneEntry struct {
+	CardID uuid.UUID
+	Entry  decklist.Entry
+}
+
+func isCommandZone(board string) bool {
+	return board == boards.Commander || board == boards.Companion
+}
+
+// checkImportedZone applies the checks SetDeckCommanders makes to the
+// commanders and companion of an import, together with those the deck
+// keeps when keep is set. It returns the entries to add: all of them, or
+// none when they do not make a legal command zone, in which case their
+// lines are reported invalid instead.
+func checkImportedZone(ctx context.Context, tx pgx.Tx, deckID uuid.UUID, keep bool, report *models.ImportReport, zone []zoneEntry) ([]zoneEntry, error) {
+	if len(zone) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(zone))
+	for i, z := range zone {
+		ids[i] = z.CardID
+	}
+	query := `
+		SELECT c.scryfall_id, c.name, c.type_line, c.oracle_text, '' AS board, 0 AS quantity FROM cards c
+		WHERE c.scryfall_id = ANY($1)
+		UNION ALL
+		SELECT c.scryfall_id, c.name, c.type_line, c.oracle_text, dc.board, dc.quantity FROM deck_cards dc
+		JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+		WHERE $2 AND dc.deck_id = $3 AND dc.board IN ($4, $5)
+	`
+	rows, err := tx.Query(ctx, query, ids, keep, deckID, boards.Commander, boards.Companion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := make(map[uuid.UUID]models.Card)
+	quantities := make(map[cardSlot]int)
+	for rows.Next() {
+		var card models.Card
+		var board string
+		var quantity int
+		if err := rows.Scan(&card.ScryfallID, &card.Name, &card.TypeLine, &card.OracleText, &board, &quantity); err != nil {
+			return nil, err
+		}
+		cards[card.ScryfallID] = card
+		if board != "" {
+			quantities[cardSlot{CardID: card.ScryfallID, Board: board}] += quantity
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, z := range zone {
+		quantities[cardSlot{CardID: z.CardID, Board: z.Entry.Board}] += z.Entry.Quantity
+	}
+
+	var commanders, companions []models.Card
+	var message string
+	for slot, quantity := range quantities {
+		if quantity > 1 {
+			message = cards[slot.CardID].Name + " can only be in the command zone once"
+		}
+		if slot.Board == boards.Commander {
+			commanders = append(commanders, cards[slot.CardID])
+		} else {
+			companions = append(companions, cards[slot.CardID])
+		}
+	}
+	if message == "" && len(commanders) > 0 {
+		// Pairs are checked in a fixed order so the message is stable.
+		sort.Slice(commanders, func(i, j int) bool { return commanders[i].Name < commanders[j].Name })
+		if err := validation.CheckCommanders(commanders); err != nil {
+			message = "Invalid commanders: " + err.Error()
+		}
+	}
+	if message == "" && len(companions) > 1 {
+		message = "A deck can have only one companion"
+	}
+	if message == "" && len(companions) == 1 {
+		if err := validation.CheckCompanion(companions[0]); err != nil {
+			message = "Invalid companion: " + err.Error()
+		}
+	}
+	if message == "" {
+		return zone, nil
+	}
+
+	for _, z := range zone {
+		for i := range report.Lines {
+			line := &report.Lines[i]
+			if line.Line == z.Entry.Line && line.Status == matchMatched {
+				line.Status = matchInvalid
+				line.Message = message
+				report.Matched--
+				report.Invalid++
+				report.CardsAdded -= z.Entry.Quantity
+			}
+		}
+	}
+	return nil, nil
+}
+
+// resolveEntries resolves each entry against the cards table, calls add for
+// every matched one and reports on all of them. The cards are looked up
+// with one query for the whole list.
+func resolveEntries(ctx context.Context, tx pgx.Tx, entries []decklist.Entry, add func(cardID uuid.UUID, entry decklist.Entry) error) (models.ImportReport, error) {
+	report := models.ImportReport{Lines: make([]models.ImportLine, 0, len(entries))}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Err == nil {
+			names = append(names, entry.Name)
+		}
+	}
+	printings, err := loadPrintings(ctx, tx, names)
+	if err != nil {
+		return report, err
+	}
+
+	for _, entry := range entries {
+		line := models.ImportLine{
+			Line:     entry.Line,
+			Text:     entry.Text,
+			Quantity: entry.Quantity,
+			Board:    entry.Board,
+		}
+
+		if entry.Err != nil {
+			line.Status = matchInvalid
+			line.Message = entry.Err.Error()
+			report.Invalid++
+			report.Lines = append(report.Lines, line)
+			continue
+		}
+
+		res := printings.resolve(entry.Name, entry.SetCode, entry.CollectorNumber)
+		line.Status = res.Status
+
+		switch res.Status {
+		case matchMatched:
+			if err := add(res.CardID, entry); err != nil {
+				return report, err
+			}
+			cardID := res.CardID
+			line.CardID = &cardID
+			line.CardName = res.CardName
+			report.Matched++
+			report.CardsAdded += entry.Quantity
+		case matchAmbiguous:
+			line.Candidates = res.Candidates
+			line.Message = "Several cards match this name"
+			report.Ambiguous++
+		default:
+			line.Message = "No card named \"" + entry.Name + "\" was found"
+			report.Unresolved++
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/odds"
+	"mana-tomb/backend/stats"
+	"mana-tomb/backend/tags"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxOddsTurn caps the turn asked about; by then most libraries are spent.
+const maxOddsTurn = 30
+
+// DrawOdds returns the probability of drawing at least "at_least" of some
+// cards by a turn. The cards are named in "cards", picked by "category"
+// ("lands", "nonlands", "type:<type>" or "tag:<tag>"), or both. The library
+// is the mainboard, so a Commander deck draws from its 99.
+// It expects DeckAccess(DeckRead) to have run first.
+func DrawOdds(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var body struct {
+			Cards     []string `json:"cards"`
+			Category  string   `json:"category"`
+			AtLeast   *int     `json:"at_least"`
+			Turn      int      `json:"turn"`
+			OnTheDraw bool     `json:"on_the_draw"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		if len(body.Cards) == 0 && body.Category == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name some cards or a category"})
+			return
+		}
+		atLeast := 1
+		if body.AtLeast != nil {
+			atLeast = *body.AtLeast
+		}
+		if atLeast < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at_least must be at least 1"})
+			return
+		}
+		if body.Turn == 0 {
+			body.Turn = 1
+		}
+		if body.Turn < 1 || body.Turn > maxOddsTurn {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("turn must be between 1 and %d", maxOddsTurn)})
+			return
+		}
+
+		matchCategory, err := parseCategory(body.Category)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		entries, err := loadDeckEntries(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		wanted := make(map[string]bool, len(body.Cards))
+		for _, name := range body.Cards {
+			wanted[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+
+		result := models.DrawOdds{
+			DeckID:    deck.ID,
+			AtLeast:   atLeast,
+			Turn:      body.Turn,
+			OnTheDraw: body.OnTheDraw,
+			Cards:     make([]string, 0),
+			ByTurn:    make([]models.TurnOdds, 0, body.Turn),
+		}
+		found := make(map[string]bool)
+		for _, e := range entries {
+			if e.Board != boards.Main {
+				continue
+			}
+			result.Library += e.Card.Quantity
+			name := strings.ToLower(e.Card.Name)
+			if wanted[name] || matchCategory != nil && matchCategory(e.Card) {
+				if !found[name] {
+					result.Cards = append(result.Cards, e.Card.Name)
+				}
+				found[name] = true
+				result.Matching += e.Card.Quantity
+			}
+		}
+
+		var missing []string
+		for _, name := range body.Cards {
+			if !found[strings.ToLower(strings.TrimSpace(name))] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Not in the deck's mainboard: " + strings.Join(missing, ", ")})
+			return
+		}
+		sort.Strings(result.Cards)
+
+		for turn := 1; turn <= body.Turn; turn++ {
+			seen := odds.CardsSeen(turn, body.OnTheDraw)
+			result.ByTurn = append(result.ByTurn, models.TurnOdds{
+				Turn:        turn,
+				CardsSeen:   seen,
+				Probability: round4(odds.AtLeast(result.Library, result.Matching, seen, atLeast)),
+			})
+		}
+		last := result.ByTurn[len(result.ByTurn)-1]
+		result.CardsSeen, result.Probability = last.CardsSeen, last.Probability
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// parseCategory returns a matcher for a category, or nil for none.
+func parseCategory(category string) (func(models.Card) bool, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	kind, value, _ := strings.Cut(category, ":")
+	value = strings.TrimSpace(value)
+
+	switch {
+	case category == "":
+		return nil, nil
+	case category == "lands":
+		return stats.IsLand, nil
+	case category == "nonlands":
+		return func(card models.Card) bool { return !stats.IsLand(card) }, nil
+	case kind == "type" && value != "":
+		// Matches card types as well as subtypes, so "type:elf" works too.
+		return func(card models.Card) bool {
+			front, _, _ := strings.Cut(card.TypeLine, " // ")
+			for _, word := range strings.Fields(strings.ToLower(front)) {
+				if word == value {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case kind == "tag" && value != "":
+		return func(card models.Card) bool { return tags.Has(card, value) }, nil
+	}
+	return nil, fmt.Errorf("unknown category %q; use \"lands\", \"nonlands\", \"type:<type>\" or \"tag:<tag>\"", category)
+}
+
+func round4(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/simulate"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultSimulationTrials = 10000
+	maxSimulationTrials     = 100000
+
+	defaultGoldfishTurns = 6
+	// A goldfish game does far more work per trial than a mulligan.
+	maxGoldfishTrials = 20000
+)
+
+// GetSampleHand deals an opening hand from the deck's mainboard. ?seed=
+// deals the same hand again; without it a seed is picked and returned.
+// It expects DeckAccess(DeckRead) to have run first.
+func GetSampleHand(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		seed := simulate.NewSeed()
+		if s := c.Query("seed"); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "seed must be a whole number"})
+				return
+			}
+			seed = n
+		}
+
+		library, err := loadLibrary(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"deck_id": deck.ID,
+			"seed":    seed,
+			"library": len(library),
+			"hand":    simulate.SampleHand(library, seed),
+		})
+	}
+}
+
+// SimulateMulligans runs seeded trials of opening hands under the London
+// mulligan with the keep rule in the request, and reports how often hands
+// are kept at each size and how many lands they hold. The first mulligan
+// is free for Commander decks unless "free_mulligan" says otherwise.
+// It expects DeckAccess(DeckRead) to have run first.
+func SimulateMulligans(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var body struct {
+			Seed         *int64   `json:"seed"`
+			Trials       int      `json:"trials"`
+			MinLands     *int     `json:"min_lands"`
+			MaxLands     *int     `json:"max_lands"`
+			MustHave     []string `json:"must_have"`
+			MustHaveAll  bool     `json:"must_have_all"`
+			FreeMulligan *bool    `json:"free_mulligan"`
+			MinHandSize  int      `json:"min_hand_size"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		opts := simulate.MulliganOptions{
+			Trials:       defaultSimulationTrials,
+			Seed:         simulate.NewSeed(),
+			Keep:         simulate.KeepRule{MinLands: 2, MaxLands: 5, MustHave: body.MustHave, MustHaveAll: body.MustHaveAll},
+			FreeMulligan: isCommanderFormat(deck),
+			MinHandSize:  4,
+		}
+		if body.Seed != nil {
+			opts.Seed = *body.Seed
+		}
+		if body.Trials != 0 {
+			opts.Trials = body.Trials
+		}
+		if body.MinLands != nil {
+			opts.Keep.MinLands = *body.MinLands
+		}
+		if body.MaxLands != nil {
+			opts.Keep.MaxLands = *body.MaxLands
+		}
+		if body.FreeMulligan != nil {
+			opts.FreeMulligan = *body.FreeMulligan
+		}
+		if body.MinHandSize != 0 {
+			opts.MinHandSize = body.MinHandSize
+		}
+
+		switch {
+		case opts.Trials < 1 || opts.Trials > maxSimulationTrials:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("trials must be between 1 and %d", maxSimulationTrials)})
+			return
+		case opts.Keep.MinLands < 0 || opts.Keep.MaxLands > simulate.HandSize || opts.Keep.MinLands > opts.Keep.MaxLands:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("min_lands and max_lands must form a range within 0 to %d", simulate.HandSize)})
+			return
+		case opts.MinHandSize < 1 || opts.MinHandSize > simulate.HandSize:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("min_hand_size must be between 1 and %d", simulate.HandSize)})
+			return
+		}
+
+		library, err := loadLibrary(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+		if missing := missingCards(library, body.MustHave); len(missing) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Not in the deck's mainboard: " + strings.Join(missing, ", ")})
+			return
+		}
+
+		c.JSON(http.StatusOK, simulate.Mulligan(library, opts))
+	}
+}
+
+// Goldfish plays the deck's mainboard against an empty board for ?turns=
+// turns and reports the mana it has each turn and how often its commanders
+// could be cast on curve. ?trials=, ?seed= and ?on_the_draw= work as they do
+// for mulligans.
+// It expects DeckAccess(DeckRead) to have run first.
+func Goldfish(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		opts := simulate.GoldfishOptions{
+			Turns:  defaultGoldfishTurns,
+			Trials: defaultSimulationTrials,
+			Seed:   simulate.NewSeed(),
+		}
+		var err error
+		if s := c.Query("seed"); s != "" {
+			if opts.Seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "seed must be a whole number"})
+				return
+			}
+		}
+		if s := c.Query("turns"); s != "" {
+			if opts.Turns, err = strconv.Atoi(s); err != nil || opts.Turns < 1 || opts.Turns > simulate.MaxGoldfishTurns {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("turns must be between 1 and %d", simulate.MaxGoldfishTurns)})
+				return
+			}
+		}
+		if s := c.Query("trials"); s != "" {
+			if opts.Trials, err = strconv.Atoi(s); err != nil || opts.Trials < 1 || opts.Trials > maxGoldfishTrials {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("trials must be between 1 and %d", maxGoldfishTrials)})
+				return
+			}
+		}
+		if s := c.Query("on_the_draw"); s != "" {
+			if opts.OnTheDraw, err = strconv.ParseBool(s); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "on_the_draw must be true or false"})
+				return
+			}
+		}
+
+		entries, err := loadDeckEntries(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+		var mainboard []models.Card
+		for _, e := range entries {
+			switch e.Board {
+			case boards.Main:
+				mainboard = append(mainboard, e.Card)
+			case boards.Commander:
+				opts.Commanders = append(opts.Commanders, e.Card)
+			}
+		}
+
+		sortByCardID(mainboard)
+		sortByCardID(opts.Commanders)
+		report := simulate.Goldfish(simulate.Library(mainboard), opts)
+		c.JSON(http.StatusOK, gin.H{
+			"deck_id":     deck.ID,
+			"seed":        report.Seed,
+			"trials":      report.Trials,
+			"library":     report.Library,
+			"turns":       opts.Turns,
+			"on_the_draw": report.OnTheDraw,
+			"by_turn":     report.ByTurn,
+			"commanders":  report.Commanders,
+		})
+	}
+}
+
+// loadLibrary returns the deck's mainboard with one entry per copy, in a
+// stable order so seeded shuffles repeat.
+func loadLibrary(ctx context.Context, q querier, deckID uuid.UUID) ([]models.Card, error) {
+	entries, err := loadDeckEntries(ctx, q, deckID)
+	if err != nil {
+		return nil, err
+	}
+	var cards []models.Card
+	for _, e := range entries {
+		if e.Board == boards.Main {
+			cards = append(cards, e.Card)
+		}
+	}
+	sortByCardID(cards)
+	return simulate.Library(cards), nil
+}
+
+// sortByCardID puts cards in Scryfall ID order before they are shuffled.
+// loadDeckEntries follows the owner's order, which can change without the
+// deck changing, and a seed must keep dealing the same hands.
+func sortByCardID(cards []models.Card) {
+	sort.Slice(cards, func(i, j int) bool {
+		return bytes.Compare(cards[i].ScryfallID[:], cards[j].ScryfallID[:]) < 0
+	})
+}
+
+// missingCards returns the names that match no card in the library.
+func missingCards(library []models.Card, names []string) []string {
+	present := make(map[string]bool, len(library))
+	for _, card := range library {
+		present[strings.ToLower(card.Name)] = true
+	}
+	var missing []string
+	for _, name := range names {
+		if !present[strings.ToLower(strings.TrimSpace(name))] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
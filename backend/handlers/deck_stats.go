@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/stats"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetDeckStats returns the mana curve, type breakdown, color pips and land
+// color sources of a deck's commander zone and mainboard.
+// It expects DeckAccess(DeckRead) to have run first.
+func GetDeckStats(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		entries, err := loadDeckEntries(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		var cards []models.Card
+		for _, e := range entries {
+			if e.Board == boards.Commander || e.Board == boards.Main {
+				cards = append(cards, e.Card)
+			}
+		}
+
+		result := stats.Compute(cards)
+		result.DeckID = deck.ID
+		c.JSON(http.StatusOK, result)
+	}
+}
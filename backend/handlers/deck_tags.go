@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/tags"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SetCardTags corrects the functional tags of one deck entry. The body maps
+// tags to true to add them, false to remove them, or null to go back to
+// what the rules say:
+//
+//	{"board": "main", "tags": {"ramp": false, "draw": true}}
+//
+// It expects DeckAccess(DeckWrite) to have run first.
+func SetCardTags(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := middleware.CurrentDeck(c).ID
+		cardID, err := uuid.Parse(c.Param("cardId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+			return
+		}
+
+		var requestBody struct {
+			Board string           `json:"board"`
+			Tags  map[string]*bool `json:"tags" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		for tag := range requestBody.Tags {
+			if !tags.Known(tag) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tag '" + tag + "'"})
+				return
+			}
+		}
+		board := requestBody.Board
+		if board == "" {
+			board = boards.Main
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deckID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+			return
+		}
+
+		var card models.Card
+		entryQuery := `
+			SELECT c.scryfall_id, c.name, c.type_line, c.oracle_text, dc.quantity, dc.tag_overrides
+			FROM deck_cards dc
+			JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+			WHERE dc.deck_id = $1 AND dc.card_scryfall_id = $2 AND dc.board = $3
+			FOR UPDATE OF dc
+		`
+		err = tx.QueryRow(context.Background(), entryQuery, deckID, cardID, board).Scan(
+			&card.ScryfallID, &card.Name, &card.TypeLine, &card.OracleText, &card.Quantity, &card.TagOverrides)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found on board '" + board + "'"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+			return
+		}
+
+		auto := tags.Classify(card)
+		var changes []string
+		for tag, on := range requestBody.Tags {
+			before := tags.Apply(auto, card.TagOverrides)
+			if on == nil {
+				delete(card.TagOverrides, tag)
+			} else {
+				card.TagOverrides[tag] = *on
+			}
+			after := tags.Apply(auto, card.TagOverrides)
+			switch had, has := hasTag(before, tag), hasTag(after, tag); {
+			case has && !had:
+				changes = append(changes, "+"+tag)
+			case had && !has:
+				changes = append(changes, "-"+tag)
+			}
+		}
+		sort.Strings(changes)
+
+		updateQuery := `UPDATE deck_cards SET tag_overrides = $4 WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`
+		if _, err := tx.Exec(context.Background(), updateQuery, deckID, cardID, board, card.TagOverrides); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tags"})
+			return
+		}
+
+		if len(changes) > 0 {
+			summary := "Tagged " + card.Name + " on " + board + ": " + strings.Join(changes, ", ")
+			if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionTagCard, summary); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+				return
+			}
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"card_id":       cardID,
+			"board":         board,
+			"tags":          tags.Apply(auto, card.TagOverrides),
+			"tag_overrides": card.TagOverrides,
+		})
+	}
+}
+
+func hasTag(list []string, tag string) bool {
+	for _, t := range list {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// maxNoteLength caps the note on a deck entry.
+const maxNoteLength = 2000
+
+// UpdateDeckEntry sets the owner's tags and note on one deck entry. Either
+// may be left out to keep it as it is; "custom_tags" replaces the list.
+// It expects DeckAccess(DeckWrite) to have run first.
+func UpdateDeckEntry(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := middleware.CurrentDeck(c).ID
+		cardID, err := uuid.Parse(c.Param("cardId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+			return
+		}
+
+		var requestBody struct {
+			Board      string    `json:"board"`
+			CustomTags *[]string `json:"custom_tags"`
+			Note       *string   `json:"note"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		if requestBody.CustomTags == nil && requestBody.Note == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+			return
+		}
+		var customTags []string
+		if requestBody.CustomTags != nil {
+			if customTags, err = tags.CleanCustom(*requestBody.CustomTags); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if requestBody.Note != nil && len([]rune(*requestBody.Note)) > maxNoteLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Notes are limited to %d characters", maxNoteLength)})
+			return
+		}
+		board := requestBody.Board
+		if board == "" {
+			board = boards.Main
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deckID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card"})
+			return
+		}
+
+		// COALESCE keeps the fields that were left out of the request.
+		var name, note string
+		var saved []string
+		updateQuery := `
+			UPDATE deck_cards dc
+			SET custom_tags = COALESCE($4, dc.custom_tags), note = COALESCE($5, dc.note)
+			FROM cards c
+			WHERE c.scryfall_id = dc.card_scryfall_id
+			  AND dc.deck_id = $1 AND dc.card_scryfall_id = $2 AND dc.board = $3
+			RETURNING c.name, dc.custom_tags, dc.note
+		`
+		err = tx.QueryRow(context.Background(), updateQuery, deckID, cardID, board, customTags, requestBody.Note).Scan(
+			&name, &saved, &note)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Card not found on board '" + board + "'"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionEditEntry, "Edited "+name+" on "+board); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"card_id":     cardID,
+			"board":       board,
+			"custom_tags": saved,
+			"note":        note,
+		})
+	}
+}
+
+// BulkTagCards adds and removes the owner's tags on several deck entries
+// at once:
+//
+//	{"cards": [{"card_id": "...", "board": "main"}], "add": ["Wincon"], "remove": ["Maybe"]}
+//
+// Removing ignores case. It expects DeckAccess(DeckWrite) to have run first.
+func BulkTagCards(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := middleware.CurrentDeck(c).ID
+
+		var requestBody struct {
+			Cards []struct {
+				CardID uuid.UUID `json:"card_id" binding:"required"`
+				Board  string    `json:"board"`
+			} `json:"cards" binding:"required,min=1,dive"`
+			Add    []string `json:"add"`
+			Remove []string `json:"remove"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			return
+		}
+		if len(requestBody.Add) == 0 && len(requestBody.Remove) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name tags to add or remove"})
+			return
+		}
+		add, err := tags.CleanCustom(requestBody.Add)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		remove := make(map[string]bool, len(requestBody.Remove))
+		for _, tag := range requestBody.Remove {
+			remove[strings.ToLower(strings.Join(strings.Fields(tag), " "))] = true
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deckID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag cards"})
+			return
+		}
+
+		selectQuery := `SELECT custom_tags FROM deck_cards WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3 FOR UPDATE`
+		updateQuery := `UPDATE deck_cards SET custom_tags = $4 WHERE deck_id = $1 AND card_scryfall_id = $2 AND board = $3`
+		type entryTags struct {
+			CardID     uuid.UUID `json:"card_id"`
+			Board      string    `json:"board"`
+			CustomTags []string  `json:"custom_tags"`
+		}
+		updated := make([]entryTags, 0, len(requestBody.Cards))
+		for _, card := range requestBody.Cards {
+			board := card.Board
+			if board == "" {
+				board = boards.Main
+			}
+
+			var current []string
+			err := tx.QueryRow(context.Background(), selectQuery, deckID, card.CardID, board).Scan(&current)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Card " + card.CardID.String() + " not found on board '" + board + "'"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag cards"})
+				return
+			}
+
+			kept := make([]string, 0, len(current)+len(add))
+			for _, tag := range current {
+				if !remove[strings.ToLower(tag)] {
+					kept = append(kept, tag)
+				}
+			}
+			next, err := tags.CleanCustom(append(kept, add...))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			if _, err := tx.Exec(context.Background(), updateQuery, deckID, card.CardID, board, next); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag cards"})
+				return
+			}
+			updated = append(updated, entryTags{CardID: card.CardID, Board: board, CustomTags: next})
+		}
+
+		summary := fmt.Sprintf("Retagged %d cards", len(updated))
+		if len(updated) == 1 {
+			summary = "Retagged 1 card"
+		}
+		if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionBulkTag, summary); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"cards": updated})
+	}
+}
+
+// GetDeckByTag shows a deck grouped by tag rather than by board: first the
+// owner's tags, alphabetically, then the functional tags, then the cards
+// with neither. A card with several tags is in each of their groups, and
+// each card says which board it is on. ?board= limits the view to one
+// board. It expects DeckAccess(DeckRead) to have run first.
+func GetDeckByTag(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		board := c.Query("board")
+
+		entries, err := loadDeckEntries(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		custom := make(map[string]*models.TagGroup)
+		functional := make(map[string]*models.TagGroup)
+		untagged := &models.TagGroup{Label: "Untagged"}
+		add := func(group *models.TagGroup, card models.TaggedCard) {
+			group.Cards = append(group.Cards, card)
+			group.Count += card.Quantity
+		}
+		for _, e := range entries {
+			if board != "" && e.Board != board {
+				continue
+			}
+			card := models.TaggedCard{Card: e.Card, Board: e.Board}
+			for _, tag := range e.Card.CustomTags {
+				// Tags that differ only in case share a group.
+				key := strings.ToLower(tag)
+				if custom[key] == nil {
+					custom[key] = &models.TagGroup{Tag: tag, Label: tag, Kind: "custom"}
+				}
+				add(custom[key], card)
+			}
+			for _, tag := range e.Card.Tags {
+				if functional[tag] == nil {
+					functional[tag] = &models.TagGroup{Tag: tag, Label: tag, Kind: "functional"}
+				}
+				add(functional[tag], card)
+			}
+			if len(e.Card.CustomTags) == 0 && len(e.Card.Tags) == 0 {
+				add(untagged, card)
+			}
+		}
+
+		groups := make([]models.TagGroup, 0, len(custom)+len(functional)+1)
+		keys := make([]string, 0, len(custom))
+		for key := range custom {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			groups = append(groups, *custom[key])
+		}
+		for _, t := range tags.All() {
+			if group := functional[t.Name]; group != nil {
+				group.Label = t.Label
+				groups = append(groups, *group)
+			}
+		}
+		if len(untagged.Cards) > 0 {
+			groups = append(groups, *untagged)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"deck_id": deck.ID, "groups": groups})
+	}
+}
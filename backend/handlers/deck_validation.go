@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ValidateDeck checks a deck against the rules of its format and returns
+// the list of violations, along with a warning if the deck is over its
+// budget. Formats without rules support only get the budget check. It
+// expects DeckAccess(DeckRead) to have run first.
+func ValidateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		entries, err := loadDeckEntries(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		// Only the commander zone and the mainboard count towards the deck;
+		// the companion is checked on its own.
+		input := validation.Deck{Format: deck.Format}
+		for _, e := range entries {
+			switch e.Board {
+			case boards.Commander:
+				input.Commanders = append(input.Commanders, e.Card)
+			case boards.Companion:
+				card := e.Card
+				input.Companion = &card
+			case boards.Main:
+				input.Mainboard = append(input.Mainboard, e.Card)
+			}
+		}
+
+		if deck.Budget != nil {
+			value, err := deckValue(context.Background(), dbpool, deck, "usd")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to price deck"})
+				return
+			}
+			input.Budget, input.Value, input.Unpriced = deck.Budget, value.Total, value.Unpriced
+		}
+
+		result := validation.Validate(input)
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultValueLimit = 10
+	maxValueLimit     = 100
+)
+
+// priceExpressions picks a printing's price in each supported currency.
+// Foil-only printings fall back to their foil price.
+var priceExpressions = map[string]string{
+	"usd": "COALESCE(c.price_usd, c.price_usd_foil)",
+	"eur": "COALESCE(c.price_eur, c.price_eur_foil)",
+	"tix": "c.price_tix",
+}
+
+// GetDeckValue totals a deck's cards at their latest known prices, board by
+// board, and lists the most expensive cards. ?currency= is usd (default),
+// eur or tix; ?limit= caps the most expensive list.
+// It expects DeckAccess(DeckRead) to have run first.
+func GetDeckValue(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		currency := strings.ToLower(c.DefaultQuery("currency", "usd"))
+		if _, ok := priceExpressions[currency]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be one of usd, eur or tix"})
+			return
+		}
+		limit := defaultValueLimit
+		if s := c.Query("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxValueLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxValueLimit)})
+				return
+			}
+			limit = n
+		}
+
+		value, err := deckValue(context.Background(), dbpool, deck, currency)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to price deck"})
+			return
+		}
+		if len(value.MostExpensive) > limit {
+			value.MostExpensive = value.MostExpensive[:limit]
+		}
+
+		c.JSON(http.StatusOK, value)
+	}
+}
+
+// deckValue prices every card in the deck. MostExpensive holds every priced
+// card on a physical board, most expensive first.
+func deckValue(ctx context.Context, q querier, deck models.Deck, currency string) (models.DeckValue, error) {
+	value := models.DeckValue{
+		DeckID:        deck.ID,
+		Currency:      currency,
+		Boards:        make([]models.BoardValue, 0),
+		MostExpensive: make([]models.CardValue, 0),
+		Unpriced:      make([]string, 0),
+	}
+
+	query := `
+		SELECT c.scryfall_id, c.name, dc.board, dc.quantity, ` + priceExpressions[currency] + `, c.prices_updated_on
+		FROM deck_cards dc
+		JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+		WHERE dc.deck_id = $1
+		ORDER BY c.name
+	`
+	rows, err := q.Query(ctx, query, deck.ID)
+	if err != nil {
+		return value, err
+	}
+	defer rows.Close()
+
+	physical := make(map[string]bool)
+	for _, name := range boards.PhysicalNames() {
+		physical[name] = true
+	}
+	byBoard := make(map[string]*models.BoardValue)
+	unpriced := make(map[string]bool)
+	for rows.Next() {
+		var card models.CardValue
+		var price *float64
+		var pricedOn *time.Time
+		if err := rows.Scan(&card.CardID, &card.Name, &card.Board, &card.Quantity, &price, &pricedOn); err != nil {
+			return value, err
+		}
+
+		board := byBoard[card.Board]
+		if board == nil {
+			board = &models.BoardValue{Board: card.Board}
+			byBoard[card.Board] = board
+		}
+		board.Cards += card.Quantity
+		if price == nil {
+			board.Unpriced += card.Quantity
+			if physical[card.Board] && !unpriced[card.Name] {
+				unpriced[card.Name] = true
+				value.Unpriced = append(value.Unpriced, card.Name)
+			}
+			continue
+		}
+
+		card.Price = *price
+		card.Total = round2(*price * float64(card.Quantity))
+		board.Total += card.Total
+		if pricedOn != nil && (value.PricesUpdatedOn == nil || pricedOn.After(*value.PricesUpdatedOn)) {
+			value.PricesUpdatedOn = pricedOn
+		}
+		if physical[card.Board] {
+			value.Total += card.Total
+			value.MostExpensive = append(value.MostExpensive, card)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return value, err
+	}
+
+	for _, board := range byBoard {
+		board.Total = round2(board.Total)
+		value.Boards = append(value.Boards, *board)
+	}
+	sort.Slice(value.Boards, func(i, j int) bool {
+		pi, pj := boards.Position(value.Boards[i].Board), boards.Position(value.Boards[j].Board)
+		if pi != pj {
+			return pi < pj
+		}
+		return value.Boards[i].Board < value.Boards[j].Board
+	})
+	sort.SliceStable(value.MostExpensive, func(i, j int) bool {
+		return value.MostExpensive[i].Price > value.MostExpensive[j].Price
+	})
+
+	value.Total = round2(value.Total)
+	if currency == "usd" && deck.Budget != nil {
+		value.Budget = deck.Budget
+		value.OverBudget = value.Total > *deck.Budget
+	}
+	return value, nil
+}
+
+// SetDeckBudget sets or, with a null budget, clears the deck's price
+// ceiling in US dollars. It expects DeckAccess(DeckWrite) to have run first.
+func SetDeckBudget(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+
+		var payload struct {
+			Budget *float64 `json:"budget"`
+		}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		if payload.Budget != nil {
+			if *payload.Budget < 0 || *payload.Budget >= 1e8 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "budget must be an amount of US dollars between 0 and 99999999.99"})
+				return
+			}
+			budget := round2(*payload.Budget)
+			payload.Budget = &budget
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck budget"})
+			return
+		}
+
+		if _, err := tx.Exec(context.Background(), `UPDATE decks SET budget = $1 WHERE id = $2`, payload.Budget, deck.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck budget"})
+			return
+		}
+
+		if summary := budgetSummary(deck.Budget, payload.Budget); summary != "" {
+			if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionBudget, summary); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+				return
+			}
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"deck_id": deck.ID, "budget": payload.Budget})
+	}
+}
+
+// budgetSummary describes a budget change for the deck history, or returns
+// "" if the budget stayed the same.
+func budgetSummary(from, to *float64) string {
+	switch {
+	case from == nil && to == nil:
+		return ""
+	case to == nil:
+		return "Removed the budget"
+	case from == nil:
+		return fmt.Sprintf("Set the budget to $%.2f", *to)
+	case *from != *to:
+		return fmt.Sprintf("Changed the budget from $%.2f to $%.2f", *from, *to)
+	}
+	return ""
+}
@@ -2,28 +2,41 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/cardsource"
+	"mana-tomb/backend/middleware"
 	"mana-tomb/backend/models"
+	"mana-tomb/backend/tags"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// ... (CreateDeck, GetUserDecks, UpdateDeck, DeleteDeck functions are unchanged) ...
-func CreateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
+// CreateDeck creates a new deck for the current user. Commanders may be
+// chosen up front by Scryfall ID; the format defaults to Commander.
+func CreateDeck(dbpool *pgxpool.Pool, source cardsource.CardSource) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var newDeckData struct {
-			Name        string `json:"name" binding:"required"`
-			Description string `json:"description"`
-			Format      string `json:"format"`
+			Name        string      `json:"name" binding:"required"`
+			Description string      `json:"description"`
+			Format      string      `json:"format"`
+			Commanders  []uuid.UUID `json:"commanders"`
+			Companion   *uuid.UUID  `json:"companion"`
 		}
 
 		if err := c.ShouldBindJSON(&newDeckData); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 			return
 		}
+		if newDeckData.Format == "" {
+			newDeckData.Format = "commander"
+		}
 
 		userIDStr, _ := c.Get("userID")
 		userID, err := uuid.Parse(userIDStr.(string))
@@ -32,18 +45,34 @@ func CreateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		commanders, companion, ok := resolveCommanderZone(c, source, commanderZone{
+			Commanders: newDeckData.Commanders,
+			Companion:  newDeckData.Companion,
+		})
+		if !ok {
+			return
+		}
+
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
 		query := `
 			INSERT INTO decks (name, description, format, user_id)
 			VALUES ($1, $2, $3, $4)
-			RETURNING id, name, description, format, user_id, created_at, updated_at
+			RETURNING id, name, description, format, user_id, is_public, created_at, updated_at
 		`
 		var createdDeck models.Deck
-		err = dbpool.QueryRow(context.Background(), query, newDeckData.Name, newDeckData.Description, newDeckData.Format, userID).Scan(
+		err = tx.QueryRow(context.Background(), query, newDeckData.Name, newDeckData.Description, newDeckData.Format, userID).Scan(
 			&createdDeck.ID,
 			&createdDeck.Name,
 			&createdDeck.Description,
 			&createdDeck.Format,
 			&createdDeck.UserID,
+			&createdDeck.IsPublic,
 			&createdDeck.CreatedAt,
 			&createdDeck.UpdatedAt,
 		)
@@ -53,6 +82,29 @@ func CreateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		rev, err := beginRevision(context.Background(), tx, createdDeck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create deck"})
+			return
+		}
+
+		if err := writeCommanderZone(context.Background(), tx, createdDeck.ID, commanders, companion); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set commanders"})
+			return
+		}
+
+		if _, err := rev.record(context.Background(), tx, &userID, actionCreate, "Created deck"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+			return
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		createdDeck.SetCommanders(commanders)
+		createdDeck.Companion = companion
 		c.JSON(http.StatusCreated, createdDeck)
 	}
 }
@@ -66,7 +118,7 @@ func GetUserDecks(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		query := `SELECT id, name, description, format, user_id, created_at, updated_at FROM decks WHERE user_id = $1 ORDER BY updated_at DESC`
+		query := `SELECT id, name, description, format, user_id, is_public, created_at, updated_at FROM decks WHERE user_id = $1 ORDER BY updated_at DESC`
 		rows, err := dbpool.Query(context.Background(), query, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve decks"})
@@ -77,25 +129,27 @@ func GetUserDecks(dbpool *pgxpool.Pool) gin.HandlerFunc {
 		decks := make([]models.Deck, 0)
 		for rows.Next() {
 			var deck models.Deck
-			if err := rows.Scan(&deck.ID, &deck.Name, &deck.Description, &deck.Format, &deck.UserID, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
+			if err := rows.Scan(&deck.ID, &deck.Name, &deck.Description, &deck.Format, &deck.UserID, &deck.IsPublic, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan deck row"})
 				return
 			}
 			decks = append(decks, deck)
 		}
+		rows.Close()
+
+		if err := loadDeckCommanders(context.Background(), dbpool, decks); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve commanders"})
+			return
+		}
 
 		c.JSON(http.StatusOK, decks)
 	}
 }
 
+// UpdateDeck expects DeckAccess(DeckWrite) to have run first.
 func UpdateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deckIDStr := c.Param("deckId")
-		deckID, err := uuid.Parse(deckIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID format"})
-			return
-		}
+		deck := middleware.CurrentDeck(c)
 
 		var deckData struct {
 			Name        string `json:"name" binding:"required"`
@@ -106,16 +160,29 @@ func UpdateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck"})
+			return
+		}
+
 		query := `
 			UPDATE decks
 			SET name = $1, description = $2, updated_at = NOW()
 			WHERE id = $3
-			RETURNING id, name, description, format, user_id, created_at, updated_at
+			RETURNING id, name, description, format, user_id, is_public, created_at, updated_at, forked_from, budget
 		`
 		var updatedDeck models.Deck
-		err = dbpool.QueryRow(context.Background(), query, deckData.Name, deckData.Description, deckID).Scan(
+		err = tx.QueryRow(context.Background(), query, deckData.Name, deckData.Description, deck.ID).Scan(
 			&updatedDeck.ID, &updatedDeck.Name, &updatedDeck.Description, &updatedDeck.Format,
-			&updatedDeck.UserID, &updatedDeck.CreatedAt, &updatedDeck.UpdatedAt,
+			&updatedDeck.UserID, &updatedDeck.IsPublic, &updatedDeck.CreatedAt, &updatedDeck.UpdatedAt, &updatedDeck.ForkedFrom, &updatedDeck.Budget,
 		)
 
 		if err != nil {
@@ -123,28 +190,43 @@ func UpdateDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		var changes []string
+		if deck.Name != updatedDeck.Name {
+			changes = append(changes, fmt.Sprintf("Renamed from %q to %q", deck.Name, updatedDeck.Name))
+		}
+		if deck.Description != updatedDeck.Description {
+			changes = append(changes, "Changed the description")
+		}
+		if len(changes) > 0 {
+			if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionUpdate, strings.Join(changes, "; ")); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+				return
+			}
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
 		c.JSON(http.StatusOK, updatedDeck)
 	}
 }
 
+// DeleteDeck expects DeckAccess(DeckWrite) to have run first.
 func DeleteDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deckIDStr := c.Param("deckId")
-		deckID, err := uuid.Parse(deckIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID format"})
-			return
-		}
+		deck := middleware.CurrentDeck(c)
 
-		query := `DELETE FROM decks WHERE id = $1`
-		cmdTag, err := dbpool.Exec(context.Background(), query, deckID)
+		query := `DELETE FROM decks WHERE id = $1 AND user_id = $2`
+		cmdTag, err := dbpool.Exec(context.Background(), query, deck.ID, deck.UserID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete deck"})
 			return
 		}
 
 		if cmdTag.RowsAffected() == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found or not owned by user"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
 			return
 		}
 
@@ -152,68 +234,181 @@ func DeleteDeck(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	}
 }
 
-// GetDeckByID is updated to fetch cards and group them by board.
+// GetDeckByID fetches a deck's cards and groups them by board.
+// It expects DeckAccess(DeckRead) to have loaded the deck already.
 func GetDeckByID(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deckIDStr := c.Param("deckId")
-		deckID, err := uuid.Parse(deckIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID format"})
+		deck := middleware.CurrentDeck(c)
+
+		if err := loadDeckCards(context.Background(), dbpool, &deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
 			return
 		}
-
-		var deck models.Deck
-		deckQuery := `SELECT id, name, description, format, user_id, created_at, updated_at FROM decks WHERE id = $1`
-		err = dbpool.QueryRow(context.Background(), deckQuery, deckID).Scan(
-			&deck.ID, &deck.Name, &deck.Description, &deck.Format, &deck.UserID, &deck.CreatedAt, &deck.UpdatedAt)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		if err := estimateBracket(context.Background(), dbpool, &deck); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate bracket"})
 			return
 		}
 
-		cardsQuery := `
-			SELECT c.scryfall_id, c.name, c.image_uris, c.mana_cost, c.cmc, c.type_line, c.oracle_text, c.colors, c.color_identity, dc.quantity, dc.board
-			FROM cards c
-			JOIN deck_cards dc ON c.scryfall_id = dc.card_scryfall_id
-			WHERE dc.deck_id = $1
-		`
-		rows, err := dbpool.Query(context.Background(), cardsQuery, deckID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+		forkQuery := `SELECT COUNT(*) FROM decks WHERE forked_from = $1`
+		if err := dbpool.QueryRow(context.Background(), forkQuery, deck.ID).Scan(&deck.ForkCount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count forks"})
 			return
 		}
-		defer rows.Close()
 
-		deck.Mainboard = make([]models.Card, 0)
-		deck.Maybeboard = make([]models.Card, 0)
+		c.JSON(http.StatusOK, deck)
+	}
+}
 
-		for rows.Next() {
-			var card models.Card
-			var board string
-			if err := rows.Scan(&card.ScryfallID, &card.Name, &card.ImageURIs, &card.ManaCost, &card.CMC, &card.TypeLine, &card.OracleText, &card.Colors, &card.ColorIdentity, &card.Quantity, &board); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan card row"})
-				return
-			}
-			// Sort cards into the correct slice based on the board.
-			if board == "maybeboard" {
-				deck.Maybeboard = append(deck.Maybeboard, card)
-			} else {
-				deck.Mainboard = append(deck.Mainboard, card)
-			}
+// deckEntry is one deck_cards row joined with its cached card data.
+type deckEntry struct {
+	Card  models.Card
+	Board string
+}
+
+// loadDeckEntries returns every card in the deck along with the board it is
+// on, in the owner's order and then by name.
+func loadDeckEntries(ctx context.Context, q querier, deckID uuid.UUID) ([]deckEntry, error) {
+	cardsQuery := `
+		SELECT c.scryfall_id, c.name, c.image_uris, c.mana_cost, c.cmc, c.type_line, c.oracle_text, c.colors, c.color_identity,
+		       c.set_code, c.collector_number, dc.quantity, dc.board, dc.tag_overrides, dc.custom_tags, dc.note, dc.sort_order
+		FROM cards c
+		JOIN deck_cards dc ON c.scryfall_id = dc.card_scryfall_id
+		WHERE dc.deck_id = $1
+		ORDER BY dc.sort_order NULLS LAST, c.name, dc.board, c.scryfall_id
+	`
+	rows, err := q.Query(ctx, cardsQuery, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]deckEntry, 0)
+	for rows.Next() {
+		var e deckEntry
+		card := &e.Card
+		if err := rows.Scan(&card.ScryfallID, &card.Name, &card.ImageURIs, &card.ManaCost, &card.CMC, &card.TypeLine, &card.OracleText, &card.Colors, &card.ColorIdentity,
+			&card.SetCode, &card.CollectorNumber, &card.Quantity, &e.Board, &card.TagOverrides,
+			&card.CustomTags, &card.Note, &card.SortOrder); err != nil {
+			return nil, err
 		}
+		card.Tags = tags.Apply(tags.Classify(*card), card.TagOverrides)
+		entries = append(entries, e)
+	}
 
-		c.JSON(http.StatusOK, deck)
+	return entries, rows.Err()
+}
+
+// loadDeckCards fills in the deck's boards from deck_cards joined with the
+// card cache. Built-in boards appear in registry order; the mainboard and
+// every custom board are always present, other built-in boards only when
+// they hold cards.
+func loadDeckCards(ctx context.Context, q querier, deck *models.Deck) error {
+	customBoards, err := loadCustomBoards(ctx, q, deck.ID)
+	if err != nil {
+		return err
+	}
+	entries, err := loadDeckEntries(ctx, q, deck.ID)
+	if err != nil {
+		return err
+	}
+
+	byBoard := make(map[string][]models.Card)
+	for _, e := range entries {
+		byBoard[e.Board] = append(byBoard[e.Board], e.Card)
+	}
+
+	deck.Boards = make([]models.Board, 0)
+	for _, zone := range boards.Builtins() {
+		cards := byBoard[zone.Name]
+		if len(cards) == 0 && zone.Name != boards.Main {
+			continue
+		}
+		if cards == nil {
+			cards = make([]models.Card, 0)
+		}
+		deck.Boards = append(deck.Boards, models.Board{Name: zone.Name, Label: zone.Label, Cards: cards})
+		delete(byBoard, zone.Name)
+	}
+	for _, name := range customBoards {
+		cards := byBoard[name]
+		if cards == nil {
+			cards = make([]models.Card, 0)
+		}
+		deck.Boards = append(deck.Boards, models.Board{Name: name, Label: name, Custom: true, Cards: cards})
+		delete(byBoard, name)
+	}
+	// Cards on a board that was never registered are still shown rather
+	// than silently dropped.
+	var unknown []string
+	for name := range byBoard {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		deck.Boards = append(deck.Boards, models.Board{Name: name, Label: name, Custom: true, Cards: byBoard[name]})
+	}
+
+	deck.SetCommanders(deck.Cards(boards.Commander))
+	if companion := deck.Cards(boards.Companion); len(companion) > 0 {
+		deck.Companion = &companion[0]
+	}
+	deck.TagCounts = tags.Count(deck.Cards(boards.Commander))
+	for tag, n := range tags.Count(deck.Cards(boards.Main)) {
+		deck.TagCounts[tag] += n
+	}
+
+	return nil
+}
+
+// loadDeckCommanders fills in the commander zone and color identity of
+// every deck in a listing with a single query.
+func loadDeckCommanders(ctx context.Context, q querier, decks []models.Deck) error {
+	if len(decks) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(decks))
+	for i, deck := range decks {
+		ids[i] = deck.ID
+	}
+
+	query := `
+		SELECT dc.deck_id, c.scryfall_id, c.name, c.image_uris, c.mana_cost, c.cmc, c.type_line, c.oracle_text, c.colors, c.color_identity,
+		       c.set_code, c.collector_number, dc.quantity
+		FROM deck_cards dc
+		JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+		WHERE dc.deck_id = ANY($1) AND dc.board = 'commander'
+		ORDER BY c.name
+	`
+	rows, err := q.Query(ctx, query, ids)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
+
+	byDeck := make(map[uuid.UUID][]models.Card)
+	for rows.Next() {
+		var deckID uuid.UUID
+		var card models.Card
+		if err := rows.Scan(&deckID, &card.ScryfallID, &card.Name, &card.ImageURIs, &card.ManaCost, &card.CMC, &card.TypeLine, &card.OracleText, &card.Colors, &card.ColorIdentity,
+			&card.SetCode, &card.CollectorNumber, &card.Quantity); err != nil {
+			return err
+		}
+		byDeck[deckID] = append(byDeck[deckID], card)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range decks {
+		decks[i].SetCommanders(byDeck[decks[i].ID])
+	}
+	return nil
 }
 
+// SetDeckVisibility expects DeckAccess(DeckWrite) to have run first.
 func SetDeckVisibility(dbpool *pgxpool.Pool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deckIDStr := c.Param("deckId")
-		deckID, err := uuid.Parse(deckIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID format"})
-			return
-		}
+		deck := middleware.CurrentDeck(c)
 
 		var payload struct {
 			IsPublic bool `json:"is_public"`
@@ -223,14 +418,27 @@ func SetDeckVisibility(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
-		userIDStr, _ := c.Get("userID")
-		// Important: Ensure the user owns the deck they are trying to modify.
+		// Ownership was checked by DeckAccess; the user_id filter is kept as a
+		// second line of defence.
+		tx, err := dbpool.Begin(context.Background())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		defer tx.Rollback(context.Background())
+
+		rev, err := beginRevision(context.Background(), tx, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck visibility"})
+			return
+		}
+
 		query := `
 			UPDATE decks 
 			SET is_public = $1 
 			WHERE id = $2 AND user_id = $3
 		`
-		cmdTag, err := dbpool.Exec(context.Background(), query, payload.IsPublic, deckID, userIDStr)
+		cmdTag, err := tx.Exec(context.Background(), query, payload.IsPublic, deck.ID, deck.UserID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck visibility"})
 			return
@@ -241,6 +449,22 @@ func SetDeckVisibility(dbpool *pgxpool.Pool) gin.HandlerFunc {
 			return
 		}
 
+		if payload.IsPublic != deck.IsPublic {
+			summary := "Made the deck private"
+			if payload.IsPublic {
+				summary = "Made the deck public"
+			}
+			if _, err := rev.record(context.Background(), tx, revisionAuthor(c), actionVisibility, summary); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record revision"})
+				return
+			}
+		}
+
+		if err := tx.Commit(context.Background()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "Deck visibility updated successfully"})
 	}
 }
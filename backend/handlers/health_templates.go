@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"mana-tomb/backend/health"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// healthTemplateInput is the body for creating or replacing a template.
+type healthTemplateInput struct {
+	Name    string                `json:"name" binding:"required"`
+	Format  string                `json:"format"`
+	Targets []models.HealthTarget `json:"targets" binding:"required"`
+}
+
+// template checks the input and returns it as a template.
+func (in healthTemplateInput) template() (models.HealthTemplate, error) {
+	t := models.HealthTemplate{Name: in.Name, Format: strings.ToLower(strings.TrimSpace(in.Format)), Targets: in.Targets}
+	if t.Format == "" {
+		t.Format = "commander"
+	}
+	err := health.CheckTemplate(&t, func(category string) error {
+		_, err := parseCategory(category)
+		return err
+	})
+	return t, err
+}
+
+// GetHealthTemplates lists the built-in templates followed by the current
+// user's own, by name. ?format= limits the list to one format.
+func GetHealthTemplates(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		format := strings.ToLower(c.Query("format"))
+
+		templates := make([]models.HealthTemplate, 0)
+		for _, t := range health.Builtins() {
+			if format == "" || t.Format == format {
+				templates = append(templates, t)
+			}
+		}
+
+		query := `
+			SELECT id, name, format, targets FROM health_templates
+			WHERE user_id = $1 AND ($2 = '' OR format = $2)
+			ORDER BY lower(name)
+		`
+		rows, err := dbpool.Query(context.Background(), query, userID, format)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve templates"})
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var t models.HealthTemplate
+			var id uuid.UUID
+			if err := rows.Scan(&id, &t.Name, &t.Format, &t.Targets); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan template row"})
+				return
+			}
+			t.ID = id.String()
+			templates = append(templates, t)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve templates"})
+			return
+		}
+
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+// CreateHealthTemplate saves a new template for the current user.
+func CreateHealthTemplate(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		var input healthTemplateInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		t, err := input.template()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var id uuid.UUID
+		query := `INSERT INTO health_templates (user_id, name, format, targets) VALUES ($1, $2, $3, $4) RETURNING id`
+		if err := dbpool.QueryRow(context.Background(), query, userID, t.Name, t.Format, t.Targets).Scan(&id); err != nil {
+			respondTemplateSaveError(c, err)
+			return
+		}
+		t.ID = id.String()
+
+		c.JSON(http.StatusCreated, t)
+	}
+}
+
+// UpdateHealthTemplate replaces one of the current user's templates.
+// Built-in templates cannot be changed; save a copy instead.
+func UpdateHealthTemplate(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		templateID, ok := ownTemplateID(c)
+		if !ok {
+			return
+		}
+		var input healthTemplateInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		t, err := input.template()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := `
+			UPDATE health_templates SET name = $3, format = $4, targets = $5, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+		`
+		cmdTag, err := dbpool.Exec(context.Background(), query, templateID, userID, t.Name, t.Format, t.Targets)
+		if err != nil {
+			respondTemplateSaveError(c, err)
+			return
+		}
+		if cmdTag.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		t.ID = templateID.String()
+
+		c.JSON(http.StatusOK, t)
+	}
+}
+
+// DeleteHealthTemplate deletes one of the current user's templates.
+func DeleteHealthTemplate(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		templateID, ok := ownTemplateID(c)
+		if !ok {
+			return
+		}
+
+		cmdTag, err := dbpool.Exec(context.Background(), `DELETE FROM health_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+			return
+		}
+		if cmdTag.RowsAffected() == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+	}
+}
+
+// ownTemplateID reads the :templateId route parameter of a user's template,
+// writing the error response itself.
+func ownTemplateID(c *gin.Context) (uuid.UUID, bool) {
+	id := c.Param("templateId")
+	if _, ok := health.Builtin(id); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Built-in templates cannot be changed"})
+		return uuid.Nil, false
+	}
+	templateID, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return uuid.Nil, false
+	}
+	return templateID, true
+}
+
+func respondTemplateSaveError(c *gin.Context, err error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have a template with that name"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save template"})
+}
+
+// loadHealthTemplate finds a template by ID: a built-in one, or one saved
+// by a user in owners. It returns pgx.ErrNoRows when there is none.
+func loadHealthTemplate(ctx context.Context, q querier, id string, owners []uuid.UUID) (models.HealthTemplate, error) {
+	if t, ok := health.Builtin(id); ok {
+		return t, nil
+	}
+	templateID, err := uuid.Parse(id)
+	if err != nil {
+		return models.HealthTemplate{}, pgx.ErrNoRows
+	}
+
+	t := models.HealthTemplate{ID: templateID.String()}
+	query := `SELECT name, format, targets FROM health_templates WHERE id = $1 AND user_id = ANY($2)`
+	err = q.QueryRow(ctx, query, templateID, owners).Scan(&t.Name, &t.Format, &t.Targets)
+	return t, err
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"mana-tomb/backend/boards"
+	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GetDeckOwnership checks a deck against the current user's collections.
+// Only boards that need real copies count (commander, companion, mainboard
+// and sideboard). Copies used by the user's other decks are listed so the
+// user can see which cards would have to be moved between decks. Missing
+// is counted against everything owned.
+// It expects DeckAccess(DeckRead) to have run first, so users can also
+// check a friend's public deck against their own cards.
+func GetDeckOwnership(dbpool *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deck := middleware.CurrentDeck(c)
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+
+		entries, err := loadDeckEntries(context.Background(), dbpool, deck.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards for deck"})
+			return
+		}
+
+		physical := boards.PhysicalNames()
+		isPhysical := make(map[string]bool, len(physical))
+		for _, name := range physical {
+			isPhysical[name] = true
+		}
+
+		needed := make(map[string]int)
+		names := make([]string, 0)
+		for _, e := range entries {
+			if !isPhysical[e.Board] {
+				continue
+			}
+			if _, ok := needed[e.Card.Name]; !ok {
+				names = append(names, e.Card.Name)
+			}
+			needed[e.Card.Name] += e.Card.Quantity
+		}
+		sort.Strings(names)
+
+		ownedQuery := `
+			SELECT c.name, SUM(cc.quantity)
+			FROM collection_cards cc
+			JOIN collections col ON col.id = cc.collection_id
+			JOIN cards c ON c.scryfall_id = cc.card_scryfall_id
+			WHERE col.user_id = $1 AND c.name = ANY($2)
+			GROUP BY c.name
+		`
+		rows, err := dbpool.Query(context.Background(), ownedQuery, userID, names)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+			return
+		}
+		defer rows.Close()
+
+		owned := make(map[string]int)
+		for rows.Next() {
+			var name string
+			var quantity int
+			if err := rows.Scan(&name, &quantity); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan collection row"})
+				return
+			}
+			owned[name] = quantity
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+			return
+		}
+		rows.Close()
+
+		committedQuery := `
+			SELECT d.id, d.name, c.name, SUM(dc.quantity)
+			FROM deck_cards dc
+			JOIN decks d ON d.id = dc.deck_id
+			JOIN cards c ON c.scryfall_id = dc.card_scryfall_id
+			WHERE d.user_id = $1 AND d.id <> $2 AND dc.board = ANY($3) AND c.name = ANY($4)
+			GROUP BY d.id, d.name, c.name
+			ORDER BY d.name
+		`
+		rows, err = dbpool.Query(context.Background(), committedQuery, userID, deck.ID, physical, names)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve other decks"})
+			return
+		}
+		defer rows.Close()
+
+		committed := make(map[string][]models.DeckCommitment)
+		for rows.Next() {
+			var commitment models.DeckCommitment
+			var name string
+			if err := rows.Scan(&commitment.DeckID, &commitment.DeckName, &name, &commitment.Quantity); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan deck row"})
+				return
+			}
+			committed[name] = append(committed[name], commitment)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve other decks"})
+			return
+		}
+
+		report := models.OwnershipReport{DeckID: deck.ID, Cards: make([]models.CardOwnership, 0, len(names))}
+		for _, name := range names {
+			card := models.CardOwnership{
+				Name:      name,
+				Needed:    needed[name],
+				Owned:     owned[name],
+				Committed: committed[name],
+			}
+			if card.Committed == nil {
+				card.Committed = make([]models.DeckCommitment, 0)
+			}
+			used := 0
+			for _, commitment := range card.Committed {
+				used += commitment.Quantity
+			}
+			card.Available = max(card.Owned-used, 0)
+			card.Missing = max(card.Needed-card.Owned, 0)
+
+			report.Needed += card.Needed
+			report.Owned += min(card.Owned, card.Needed)
+			report.Missing += card.Missing
+			report.Cards = append(report.Cards, card)
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
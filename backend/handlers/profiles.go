@@ -27,7 +27,7 @@ func GetUserProfile(dbpool *pgxpool.Pool) gin.HandlerFunc {
 
 		// 2. Fetch all decks that belong to this user AND are marked as public.
 		decksQuery := `
-			SELECT id, name, description, format, user_id, created_at, updated_at 
+			SELECT id, name, description, format, user_id, is_public, created_at, updated_at 
 			FROM decks 
 			WHERE user_id = $1 AND is_public = TRUE 
 			ORDER BY updated_at DESC
@@ -42,12 +42,22 @@ func GetUserProfile(dbpool *pgxpool.Pool) gin.HandlerFunc {
 		publicDecks := make([]models.Deck, 0)
 		for rows.Next() {
 			var deck models.Deck
-			if err := rows.Scan(&deck.ID, &deck.Name, &deck.Description, &deck.Format, &deck.UserID, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
+			if err := rows.Scan(&deck.ID, &deck.Name, &deck.Description, &deck.Format, &deck.UserID, &deck.IsPublic, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan deck row"})
 				return
 			}
 			publicDecks = append(publicDecks, deck)
 		}
+		rows.Close()
+
+		if err := loadDeckCommanders(context.Background(), dbpool, publicDecks); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve commanders"})
+			return
+		}
+		if err := loadDeckBrackets(context.Background(), dbpool, publicDecks); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate brackets"})
+			return
+		}
 
 		profile := models.Profile{
 			Username:    user.Username,
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Revision actions, as stored in deck_revisions.action.
+const (
+	actionCreate        = "create"
+	actionUpdate        = "update"
+	actionVisibility    = "visibility"
+	actionAddCard       = "add_card"
+	actionSetQuantity   = "set_quantity"
+	actionRemoveCard    = "remove_card"
+	actionMoveCards     = "move_cards"
+	actionSetCommanders = "set_commanders"
+	actionImport        = "import"
+	actionCreateBoard   = "create_board"
+	actionDeleteBoard   = "delete_board"
+	actionRestore       = "restore"
+	actionFork          = "fork"
+	actionBudget        = "budget"
+	actionTagCard       = "tag_card"
+	actionEditEntry     = "edit_entry"
+	actionBulkTag       = "bulk_tag"
+	actionSortBoard     = "sort_board"
+)
+
+// cardSlot identifies one deck_cards row within a deck.
+type cardSlot struct {
+	CardID uuid.UUID
+	Board  string
+}
+
+// revision records a change to a deck. It is started before the change is
+// made, inside the same transaction, and snapshots the deck's cards so the
+// change can be stored as per-card deltas afterwards. Starting a revision
+// locks the deck row, so changes to one deck are applied one at a time.
+type revision struct {
+	deckID uuid.UUID
+	before map[cardSlot]int
+}
+
+func beginRevision(ctx context.Context, tx pgx.Tx, deckID uuid.UUID) (*revision, error) {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM decks WHERE id = $1 FOR UPDATE`, deckID); err != nil {
+		return nil, err
+	}
+	before, err := snapshotDeck(ctx, tx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	return &revision{deckID: deckID, before: before}, nil
+}
+
+// record stores the revision with the card changes made since it began. A
+// change that touched no cards is only recorded when it has a summary, so
+// no-op requests do not clutter the history. It returns the new revision
+// number, or 0 when nothing was recorded.
+func (r *revision) record(ctx context.Context, tx pgx.Tx, userID *uuid.UUID, action, summary string) (int, error) {
+	after, err := snapshotDeck(ctx, tx, r.deckID)
+	if err != nil {
+		return 0, err
+	}
+
+	deltas := make(map[cardSlot]int)
+	for slot, quantity := range after {
+		if d := quantity - r.before[slot]; d != 0 {
+			deltas[slot] = d
+		}
+	}
+	for slot, quantity := range r.before {
+		if _, ok := after[slot]; !ok {
+			deltas[slot] = -quantity
+		}
+	}
+	if len(deltas) == 0 && summary == "" {
+		return 0, nil
+	}
+
+	var id int64
+	var number int
+	insertQuery := `
+		INSERT INTO deck_revisions (deck_id, revision, user_id, action, summary)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4 FROM deck_revisions WHERE deck_id = $1
+		RETURNING id, revision
+	`
+	if err := tx.QueryRow(ctx, insertQuery, r.deckID, userID, action, summary).Scan(&id, &number); err != nil {
+		return 0, err
+	}
+
+	if len(deltas) > 0 {
+		batch := &pgx.Batch{}
+		for slot, d := range deltas {
+			batch.Queue(`INSERT INTO deck_revision_changes (revision_id, card_scryfall_id, board, quantity_delta) VALUES ($1, $2, $3, $4)`,
+				id, slot.CardID, slot.Board, d)
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE decks SET updated_at = NOW() WHERE id = $1`, r.deckID); err != nil {
+		return 0, err
+	}
+	return number, nil
+}
+
+// snapshotDeck returns the quantity of every card on every board of a deck.
+func snapshotDeck(ctx context.Context, q querier, deckID uuid.UUID) (map[cardSlot]int, error) {
+	rows, err := q.Query(ctx, `SELECT card_scryfall_id, board, quantity FROM deck_cards WHERE deck_id = $1`, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshot := make(map[cardSlot]int)
+	for rows.Next() {
+		var slot cardSlot
+		var quantity int
+		if err := rows.Scan(&slot.CardID, &slot.Board, &quantity); err != nil {
+			return nil, err
+		}
+		snapshot[slot] = quantity
+	}
+	return snapshot, rows.Err()
+}
+
+// revisionAuthor returns the logged-in user's ID for recording who made a
+// change, or nil if the request carries no valid user.
+func revisionAuthor(c *gin.Context) *uuid.UUID {
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
@@ -0,0 +1,152 @@
+// Package health checks a deck's makeup against a template: so many lands,
+// so much ramp, draw and removal. Built-in templates are defined here;
+// users keep their own in the health_templates table.
+package health
+
+import (
+	"errors"
+	"strings"
+
+	"mana-tomb/backend/models"
+)
+
+// Line statuses.
+const (
+	StatusShort = "short"
+	StatusOver  = "over"
+	StatusOK    = "ok"
+)
+
+// Limits on users' templates.
+const (
+	MaxNameLength = 255
+	MaxTargets    = 30
+	MaxCount      = 250
+)
+
+var (
+	ErrInvalidName    = errors.New("template names must be between 1 and 255 characters")
+	ErrNoTargets      = errors.New("a template needs at least one target")
+	ErrTooManyTargets = errors.New("a template can have at most 30 targets")
+	ErrNoCategory     = errors.New("every target needs a category")
+	ErrInvalidCount   = errors.New("target counts must be between 0 and 250")
+	ErrRepeatedTarget = errors.New("each category can only be targeted once")
+)
+
+// builtins are listed in the order they are offered.
+var builtins = []models.HealthTemplate{
+	{
+		ID:      "command-zone",
+		Name:    "Command Zone 8x8",
+		Format:  "commander",
+		Builtin: true,
+		Targets: []models.HealthTarget{
+			{Category: "lands", Count: 38},
+			{Category: "tag:ramp", Count: 10},
+			{Category: "tag:draw", Count: 10},
+			{Category: "tag:removal", Count: 10},
+			{Category: "tag:wipe", Count: 3},
+		},
+	},
+}
+
+// Builtins returns the built-in templates.
+func Builtins() []models.HealthTemplate {
+	templates := make([]models.HealthTemplate, len(builtins))
+	for i, t := range builtins {
+		t.Targets = append([]models.HealthTarget(nil), t.Targets...)
+		templates[i] = t
+	}
+	return templates
+}
+
+// Builtin looks up a built-in template by ID.
+func Builtin(id string) (models.HealthTemplate, bool) {
+	for _, t := range Builtins() {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return models.HealthTemplate{}, false
+}
+
+// DefaultFor returns the first built-in template for a format. Decks
+// without a format are Commander decks.
+func DefaultFor(format string) (models.HealthTemplate, bool) {
+	if format == "" {
+		format = "commander"
+	}
+	for _, t := range Builtins() {
+		if t.Format == format {
+			return t, true
+		}
+	}
+	return models.HealthTemplate{}, false
+}
+
+// CheckTemplate checks a user's template before it is saved, and tidies
+// its name and categories. validCategory reports whether a category can be
+// counted.
+func CheckTemplate(t *models.HealthTemplate, validCategory func(string) error) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || len([]rune(t.Name)) > MaxNameLength {
+		return ErrInvalidName
+	}
+	switch {
+	case len(t.Targets) == 0:
+		return ErrNoTargets
+	case len(t.Targets) > MaxTargets:
+		return ErrTooManyTargets
+	}
+	seen := make(map[string]bool, len(t.Targets))
+	for i := range t.Targets {
+		target := &t.Targets[i]
+		target.Category = strings.ToLower(strings.TrimSpace(target.Category))
+		if target.Category == "" {
+			return ErrNoCategory
+		}
+		if err := validCategory(target.Category); err != nil {
+			return err
+		}
+		if target.Count < 0 || target.Count > MaxCount {
+			return ErrInvalidCount
+		}
+		if seen[target.Category] {
+			return ErrRepeatedTarget
+		}
+		seen[target.Category] = true
+	}
+	return nil
+}
+
+// Evaluate compares the deck's counts with the template. count returns how
+// many cards of the deck fall in a category.
+func Evaluate(t models.HealthTemplate, count func(category string) int) models.DeckHealth {
+	result := models.DeckHealth{
+		Template:   models.TemplateRef{ID: t.ID, Name: t.Name},
+		Lines:      make([]models.HealthLine, 0, len(t.Targets)),
+		Shortfalls: make([]models.HealthLine, 0),
+		Surpluses:  make([]models.HealthLine, 0),
+	}
+	for _, target := range t.Targets {
+		n := count(target.Category)
+		line := models.HealthLine{
+			Category:   target.Category,
+			Target:     target.Count,
+			Count:      n,
+			Difference: n - target.Count,
+			Status:     StatusOK,
+		}
+		switch {
+		case line.Difference < 0:
+			line.Status = StatusShort
+			result.Shortfalls = append(result.Shortfalls, line)
+		case line.Difference > 0:
+			line.Status = StatusOver
+			result.Surpluses = append(result.Surpluses, line)
+		}
+		result.Lines = append(result.Lines, line)
+	}
+	result.Healthy = len(result.Shortfalls) == 0
+	return result
+}
@@ -2,19 +2,19 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
 
+	"mana-tomb/backend/cardsource"
+	"mana-tomb/backend/database"
 	"mana-tomb/backend/handlers"
 	"mana-tomb/backend/middleware"
+	"mana-tomb/backend/tags"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/sessions"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
@@ -45,32 +45,28 @@ func main() {
 	// ----------------------
 
 	// ... (database setup) ...
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
-
-	dbCert := os.Getenv("DB_CERT")
-	if dbCert != "" {
-		certPath := filepath.Join(os.TempDir(), "db-ca-certificate.crt")
-		err := os.WriteFile(certPath, []byte(dbCert), 0644)
-		if err != nil {
-			log.Fatalf("Unable to write database certificate to temp file: %v", err)
-		}
-		connStr = fmt.Sprintf("%s sslmode=require sslrootcert=%s", connStr, certPath)
-	} else {
-		connStr = fmt.Sprintf("%s sslmode=disable", connStr)
-	}
-
-	dbpool, err := pgxpool.New(context.Background(), connStr)
+	dbpool, err := database.Connect(context.Background())
 	if err != nil {
 		log.Fatalf("Unable to create connection pool: %v\n", err)
 	}
 	defer dbpool.Close()
 
+	// --- Card Source Setup ---
+	// Cards come from Scryfall by default. CARD_SOURCE=local serves them from
+	// our own cards table (filled by cmd/cardsync), and CARD_SOURCE_FILE
+	// points at a local JSON file of Scryfall card objects. Both work offline.
+	var source cardsource.CardSource = cardsource.NewScryfall()
+	if os.Getenv("CARD_SOURCE") == "local" {
+		source = cardsource.NewDatabase(dbpool)
+	}
+	if cardFile := os.Getenv("CARD_SOURCE_FILE"); cardFile != "" {
+		fileSource, err := cardsource.NewFile(cardFile)
+		if err != nil {
+			log.Fatalf("Unable to load card source file: %v", err)
+		}
+		source = fileSource
+	}
+
 	// --- Router Setup ---
 	router := gin.Default()
 
@@ -89,6 +85,9 @@ func main() {
 		api.GET("/health", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
+		api.GET("/tags", func(c *gin.Context) {
+			c.JSON(http.StatusOK, tags.All())
+		})
 
 		auth := api.Group("/users")
 		{
@@ -101,22 +100,87 @@ func main() {
 			profiles.GET("/:username", handlers.GetUserProfile(dbpool))
 		}
 
+		// Deck access rules: anyone may read a public deck, only the owner
+		// may read a private one or change anything.
+		canRead := middleware.DeckAccess(dbpool, middleware.DeckRead)
+		canWrite := middleware.DeckAccess(dbpool, middleware.DeckWrite)
+
+		publicDecks := api.Group("/decks")
+		publicDecks.Use(middleware.OptionalAuth(store))
+		{
+			publicDecks.GET("/compare", handlers.CompareDecks(dbpool))
+			publicDecks.GET("/:deckId", canRead, handlers.GetDeckByID(dbpool))
+			publicDecks.GET("/:deckId/export", canRead, handlers.ExportDeck(dbpool))
+			publicDecks.GET("/:deckId/validate", canRead, handlers.ValidateDeck(dbpool))
+			publicDecks.GET("/:deckId/value", canRead, handlers.GetDeckValue(dbpool))
+			publicDecks.GET("/:deckId/stats", canRead, handlers.GetDeckStats(dbpool))
+			publicDecks.GET("/:deckId/health", canRead, handlers.GetDeckHealth(dbpool))
+			publicDecks.GET("/:deckId/combos", canRead, handlers.GetDeckCombos(dbpool))
+			publicDecks.POST("/:deckId/odds", canRead, handlers.DrawOdds(dbpool))
+			publicDecks.GET("/:deckId/sample-hand", canRead, handlers.GetSampleHand(dbpool))
+			publicDecks.POST("/:deckId/simulate", canRead, handlers.SimulateMulligans(dbpool))
+			publicDecks.GET("/:deckId/goldfish", canRead, handlers.Goldfish(dbpool))
+			publicDecks.GET("/:deckId/boards", canRead, handlers.GetBoards(dbpool))
+			publicDecks.GET("/:deckId/by-tag", canRead, handlers.GetDeckByTag(dbpool))
+			publicDecks.GET("/:deckId/history", canRead, handlers.GetDeckHistory(dbpool))
+			publicDecks.GET("/:deckId/diff", canRead, handlers.DiffDeck(dbpool))
+		}
+
 		protected := api.Group("/")
 		protected.Use(middleware.AuthRequired(store))
 		{
 			protected.GET("/users/me", handlers.GetCurrentUser(dbpool))
 			protected.POST("/users/logout", handlers.LogoutUser(store))
+			protected.GET("/cards/search", handlers.SearchCards(source))
 
 			decks := protected.Group("/decks")
 			{
-				decks.POST("/", handlers.CreateDeck(dbpool))
+				decks.POST("/", handlers.CreateDeck(dbpool, source))
+				decks.POST("/import", handlers.CreateDeckFromList(dbpool))
 				decks.GET("/", handlers.GetUserDecks(dbpool))
-				decks.GET("/:deckId", handlers.GetDeckByID(dbpool))
-				decks.PUT("/:deckId", handlers.UpdateDeck(dbpool))
-				decks.DELETE("/:deckId", handlers.DeleteDeck(dbpool))
-				decks.PUT("/:deckId/visibility", handlers.SetDeckVisibility(dbpool))
-				decks.POST("/:deckId/cards", handlers.AddCardToDeck(dbpool))
-				decks.DELETE("/:deckId/cards/:cardId", handlers.RemoveCardFromDeck(dbpool))
+				decks.PUT("/:deckId", canWrite, handlers.UpdateDeck(dbpool))
+				decks.DELETE("/:deckId", canWrite, handlers.DeleteDeck(dbpool))
+				decks.PUT("/:deckId/visibility", canWrite, handlers.SetDeckVisibility(dbpool))
+				decks.PUT("/:deckId/budget", canWrite, handlers.SetDeckBudget(dbpool))
+				decks.PUT("/:deckId/commanders", canWrite, handlers.SetDeckCommanders(dbpool, source))
+				decks.POST("/:deckId/cards", canWrite, handlers.AddCardToDeck(dbpool, source))
+				decks.PATCH("/:deckId/cards/:cardId", canWrite, handlers.SetCardQuantity(dbpool))
+				decks.DELETE("/:deckId/cards/:cardId", canWrite, handlers.RemoveCardFromDeck(dbpool))
+				decks.POST("/:deckId/cards/:cardId/move", canWrite, handlers.MoveCards(dbpool))
+				decks.PATCH("/:deckId/cards/:cardId/tags", canWrite, handlers.SetCardTags(dbpool))
+				decks.PATCH("/:deckId/cards/:cardId/entry", canWrite, handlers.UpdateDeckEntry(dbpool))
+				decks.POST("/:deckId/custom-tags", canWrite, handlers.BulkTagCards(dbpool))
+				decks.POST("/:deckId/boards", canWrite, handlers.CreateBoard(dbpool))
+				decks.DELETE("/:deckId/boards/:board", canWrite, handlers.DeleteBoard(dbpool))
+				decks.PUT("/:deckId/boards/:board/order", canWrite, handlers.SetBoardOrder(dbpool))
+				decks.POST("/:deckId/import", canWrite, handlers.ImportDecklist(dbpool))
+				decks.POST("/:deckId/restore/:revision", canWrite, handlers.RestoreDeck(dbpool))
+				// Owners can fork their own decks and anyone can fork a public one.
+				decks.POST("/:deckId/fork", canRead, handlers.ForkDeck(dbpool))
+				decks.GET("/:deckId/ownership", canRead, handlers.GetDeckOwnership(dbpool))
+			}
+
+			templates := protected.Group("/health-templates")
+			{
+				templates.GET("/", handlers.GetHealthTemplates(dbpool))
+				templates.POST("/", handlers.CreateHealthTemplate(dbpool))
+				templates.PUT("/:templateId", handlers.UpdateHealthTemplate(dbpool))
+				templates.DELETE("/:templateId", handlers.DeleteHealthTemplate(dbpool))
+			}
+
+			collections := protected.Group("/collections")
+			{
+				collections.GET("/", handlers.GetCollections(dbpool))
+				collections.POST("/", handlers.CreateCollection(dbpool))
+				collections.GET("/:collectionId", handlers.GetCollection(dbpool))
+				collections.PUT("/:collectionId", handlers.UpdateCollection(dbpool))
+				collections.DELETE("/:collectionId", handlers.DeleteCollection(dbpool))
+				collections.POST("/:collectionId/cards", handlers.AddCollectionCard(dbpool, source))
+				collections.PATCH("/:collectionId/cards/:entryId", handlers.UpdateCollectionCard(dbpool))
+				collections.DELETE("/:collectionId/cards/:entryId", handlers.DeleteCollectionCard(dbpool))
+				collections.POST("/:collectionId/import", handlers.ImportCollection(dbpool))
+				collections.POST("/:collectionId/import/csv", handlers.ImportCollectionCSV(dbpool))
+				collections.GET("/:collectionId/export/csv", handlers.ExportCollectionCSV(dbpool))
 			}
 		}
 	}
@@ -0,0 +1,37 @@
+package manacost
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	symbolPattern   = regexp.MustCompile(`\{([^{}]*)\}`)
+	reminderPattern = regexp.MustCompile(`\([^()]*\)`)
+)
+
+// ColorIdentity returns a card's color identity in WUBRG order: the colors
+// of the mana symbols in its mana cost and rules text, reminder text left
+// out. Colors that appear in neither, such as a color indicator, can be
+// passed in extra; the identity Scryfall reports is a good source for them.
+func ColorIdentity(manaCost, rulesText string, extra []string) []string {
+	seen := make(map[string]bool)
+	addSymbols := func(text string) {
+		for _, m := range symbolPattern.FindAllStringSubmatch(text, -1) {
+			// Non-mana symbols such as {T} or {E} simply fail to parse.
+			if symbol, err := ParseSymbol(m[1]); err == nil {
+				for _, color := range symbol.Colors {
+					seen[color] = true
+				}
+			}
+		}
+	}
+	addSymbols(manaCost)
+	addSymbols(reminderPattern.ReplaceAllString(rulesText, ""))
+	for _, color := range extra {
+		if color = strings.ToUpper(color); isColor(color) {
+			seen[color] = true
+		}
+	}
+	return inOrder(seen)
+}
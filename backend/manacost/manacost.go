@@ -0,0 +1,253 @@
+// Package manacost parses mana costs written the way Scryfall writes them,
+// such as "{2}{W/U}{G/P}{X}", and answers questions about them: mana value,
+// colored pips, devotion and color identity. It works from the cost itself,
+// so the numbers do not depend on cached cmc or colors fields.
+package manacost
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Colors lists the five colors in WUBRG order.
+var Colors = []string{"W", "U", "B", "R", "G"}
+
+// Symbol is one mana symbol of a cost.
+type Symbol struct {
+	// Text is the symbol as written, braces included.
+	Text string
+	// Generic is the generic mana in the symbol: 3 for {3}, 2 for {2/W}.
+	Generic int
+	// Colors are the colors that can pay the symbol, in WUBRG order. Hybrid
+	// symbols have two.
+	Colors []string
+	// Colorless is set for {C}, which needs colorless mana specifically.
+	Colorless bool
+	// Phyrexian symbols can be paid with 2 life instead.
+	Phyrexian bool
+	// Snow is set for {S}, which needs mana from a snow source.
+	Snow bool
+	// X is set for {X}, {Y} and {Z}.
+	X bool
+	// Half is set for the half-mana symbols of Un-sets, such as {HR}.
+	Half bool
+}
+
+// Hybrid reports whether the symbol can be paid in more than one way other
+// than with life: {W/U}, {2/W} or {C/W}.
+func (s Symbol) Hybrid() bool {
+	ways := len(s.Colors)
+	if s.Generic > 0 {
+		ways++
+	}
+	if s.Colorless {
+		ways++
+	}
+	return ways > 1
+}
+
+// ManaValue is what the symbol adds to a card's mana value. X counts as
+// zero and a hybrid symbol as its largest component.
+func (s Symbol) ManaValue() float64 {
+	switch {
+	case s.X:
+		return 0
+	case s.Half:
+		return 0.5
+	case s.Generic > 0:
+		return float64(s.Generic)
+	case len(s.Colors) > 0 || s.Colorless || s.Snow || s.Phyrexian:
+		return 1
+	}
+	return 0
+}
+
+// Cost is a parsed mana cost.
+type Cost struct {
+	Symbols []Symbol
+}
+
+var costPattern = regexp.MustCompile(`\{([^{}]*)\}|\s*//\s*|\s+`)
+
+// Parse reads a mana cost. The halves of split cards, separated by "//",
+// are read as one cost, the way the rules add them up for mana value. An
+// empty string is a card without a mana cost.
+func Parse(s string) (Cost, error) {
+	var cost Cost
+	rest := s
+	for rest != "" {
+		loc := costPattern.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			return Cost{}, fmt.Errorf("unexpected %q in mana cost %q", rest, s)
+		}
+		if loc[2] >= 0 {
+			symbol, err := ParseSymbol(rest[loc[2]:loc[3]])
+			if err != nil {
+				return Cost{}, fmt.Errorf("mana cost %q: %w", s, err)
+			}
+			cost.Symbols = append(cost.Symbols, symbol)
+		}
+		rest = rest[loc[1]:]
+	}
+	return cost, nil
+}
+
+// ParseSymbol reads one mana symbol, given without its braces, such as
+// "W/U" or "2".
+func ParseSymbol(text string) (Symbol, error) {
+	symbol := Symbol{Text: "{" + text + "}"}
+	upper := strings.ToUpper(strings.TrimSpace(text))
+
+	if upper == "½" {
+		symbol.Half = true
+		return symbol, nil
+	}
+	if n, err := strconv.Atoi(upper); err == nil && n >= 0 {
+		symbol.Generic = n
+		return symbol, nil
+	}
+	if len(upper) == 2 && upper[0] == 'H' && isColor(upper[1:]) {
+		symbol.Half = true
+		symbol.Colors = []string{upper[1:]}
+		return symbol, nil
+	}
+
+	colors := make(map[string]bool)
+	for _, part := range strings.Split(upper, "/") {
+		switch {
+		case part == "P":
+			symbol.Phyrexian = true
+		case part == "C":
+			symbol.Colorless = true
+		case part == "S":
+			symbol.Snow = true
+		case part == "X" || part == "Y" || part == "Z":
+			symbol.X = true
+		case isColor(part):
+			colors[part] = true
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return Symbol{}, fmt.Errorf("unknown mana symbol {%s}", text)
+			}
+			symbol.Generic = n
+		}
+	}
+	if len(colors) > 0 {
+		symbol.Colors = inOrder(colors)
+	}
+	return symbol, nil
+}
+
+// ManaValue adds up the mana value of every symbol.
+func (c Cost) ManaValue() float64 {
+	total := 0.0
+	for _, s := range c.Symbols {
+		total += s.ManaValue()
+	}
+	return total
+}
+
+// Pips counts the colored symbols of each color. A hybrid symbol counts
+// once for each of its colors. {C} symbols are counted under "C".
+func (c Cost) Pips() map[string]int {
+	pips := make(map[string]int)
+	for _, s := range c.Symbols {
+		for _, color := range s.Colors {
+			pips[color]++
+		}
+		if s.Colorless {
+			pips["C"]++
+		}
+	}
+	return pips
+}
+
+// Colors returns the colors of the cost in WUBRG order.
+func (c Cost) Colors() []string {
+	seen := make(map[string]bool)
+	for _, s := range c.Symbols {
+		for _, color := range s.Colors {
+			seen[color] = true
+		}
+	}
+	return inOrder(seen)
+}
+
+// Hybrid counts the hybrid symbols.
+func (c Cost) Hybrid() int {
+	return c.count(Symbol.Hybrid)
+}
+
+// Phyrexian counts the Phyrexian symbols.
+func (c Cost) Phyrexian() int {
+	return c.count(func(s Symbol) bool { return s.Phyrexian })
+}
+
+// Snow counts the {S} symbols.
+func (c Cost) Snow() int {
+	return c.count(func(s Symbol) bool { return s.Snow })
+}
+
+// X counts the {X}, {Y} and {Z} symbols.
+func (c Cost) X() int {
+	return c.count(func(s Symbol) bool { return s.X })
+}
+
+func (c Cost) count(match func(Symbol) bool) int {
+	n := 0
+	for _, s := range c.Symbols {
+		if match(s) {
+			n++
+		}
+	}
+	return n
+}
+
+// Devotion counts the mana symbols in the cost that are of any of the given
+// colors. Hybrid and Phyrexian symbols count once.
+func (c Cost) Devotion(colors ...string) int {
+	want := make(map[string]bool, len(colors))
+	for _, color := range colors {
+		want[color] = true
+	}
+	return c.count(func(s Symbol) bool {
+		for _, color := range s.Colors {
+			if want[color] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Devotion returns a player's devotion to the given colors with the
+// permanents whose costs are given.
+func Devotion(permanents []Cost, colors ...string) int {
+	total := 0
+	for _, cost := range permanents {
+		total += cost.Devotion(colors...)
+	}
+	return total
+}
+
+func isColor(s string) bool {
+	for _, color := range Colors {
+		if s == color {
+			return true
+		}
+	}
+	return false
+}
+
+func inOrder(seen map[string]bool) []string {
+	colors := make([]string, 0, len(seen))
+	for _, color := range Colors {
+		if seen[color] {
+			colors = append(colors, color)
+		}
+	}
+	return colors
+}
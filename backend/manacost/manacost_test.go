@@ -0,0 +1,64 @@
+package manacost
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		cost      string
+		manaValue float64
+		colors    []string
+		hybrid    int
+		phyrexian int
+		x         int
+	}{
+		{"empty", "", 0, []string{}, 0, 0, 0},
+		{"generic and colored", "{2}{W}{W}", 4, []string{"W"}, 0, 0, 0},
+		{"two-color hybrid", "{W/U}{W/U}", 2, []string{"W", "U"}, 2, 0, 0},
+		{"monocolored hybrid", "{2/W}", 2, []string{"W"}, 1, 0, 0},
+		{"colorless hybrid", "{C/W}", 1, []string{"W"}, 1, 0, 0},
+		{"Phyrexian", "{1}{G/P}", 2, []string{"G"}, 0, 1, 0},
+		{"hybrid Phyrexian", "{G/W/P}", 1, []string{"W", "G"}, 1, 1, 0},
+		{"X", "{X}{R}", 1, []string{"R"}, 0, 0, 1},
+		{"XX", "{X}{X}{G}", 1, []string{"G"}, 0, 0, 2},
+		{"half mana", "{HR}", 0.5, []string{"R"}, 0, 0, 0},
+		{"half generic", "{½}", 0.5, []string{}, 0, 0, 0},
+		{"split halves add up", "{1}{R} // {U}", 3, []string{"U", "R"}, 0, 0, 0},
+		{"empty back face", "{2}{G} // ", 3, []string{"G"}, 0, 0, 0},
+		{"snow and colorless", "{S}{C}", 2, []string{}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, err := Parse(tt.cost)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.cost, err)
+			}
+			if got := cost.ManaValue(); got != tt.manaValue {
+				t.Errorf("mana value: got %v, want %v", got, tt.manaValue)
+			}
+			if got := cost.Colors(); !reflect.DeepEqual(got, tt.colors) {
+				t.Errorf("colors: got %v, want %v", got, tt.colors)
+			}
+			if got := cost.Hybrid(); got != tt.hybrid {
+				t.Errorf("hybrid: got %d, want %d", got, tt.hybrid)
+			}
+			if got := cost.Phyrexian(); got != tt.phyrexian {
+				t.Errorf("Phyrexian: got %d, want %d", got, tt.phyrexian)
+			}
+			if got := cost.X(); got != tt.x {
+				t.Errorf("X: got %d, want %d", got, tt.x)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, cost := range []string{"{Q}", "2WW", "{2}{W", "{-1}"} {
+		if _, err := Parse(cost); err == nil {
+			t.Errorf("Parse(%q): got no error", cost)
+		}
+	}
+}
@@ -32,3 +32,19 @@ func AuthRequired(store *sessions.CookieStore) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuth behaves like AuthRequired but never rejects the request.
+// It is used on routes that anonymous visitors may also reach, such as
+// reading a public deck, so that a logged-in owner is still recognised.
+func OptionalAuth(store *sessions.CookieStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, err := store.Get(c.Request, "mana-tomb-session")
+		if err == nil {
+			if userID, ok := session.Values["user_id"].(string); ok && userID != "" {
+				c.Set("userID", userID)
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeckPermission describes what a request wants to do with a deck.
+type DeckPermission int
+
+const (
+	// DeckRead allows the owner, or anyone when the deck is public.
+	DeckRead DeckPermission = iota
+	// DeckWrite allows only the owner.
+	DeckWrite
+)
+
+var (
+	ErrDeckNotFound  = errors.New("deck not found")
+	ErrDeckForbidden = errors.New("you do not have permission to access this deck")
+)
+
+// CheckDeckAccess decides whether userID may use deck with the given permission.
+// An empty userID stands for an anonymous visitor.
+func CheckDeckAccess(deck models.Deck, userID string, perm DeckPermission) error {
+	isOwner := userID != "" && deck.UserID.String() == userID
+	if isOwner {
+		return nil
+	}
+	if perm == DeckRead && deck.IsPublic {
+		return nil
+	}
+	return ErrDeckForbidden
+}
+
+// deckFetcher reads a deck's metadata, returning ErrDeckNotFound when there
+// is no such deck.
+type deckFetcher func(ctx context.Context, deckID uuid.UUID) (models.Deck, error)
+
+// fetchDeck returns a deckFetcher reading from the decks table.
+func fetchDeck(dbpool *pgxpool.Pool) deckFetcher {
+	return func(ctx context.Context, deckID uuid.UUID) (models.Deck, error) {
+		var deck models.Deck
+		query := `SELECT id, name, description, format, user_id, is_public, created_at, updated_at, forked_from, budget FROM decks WHERE id = $1`
+		err := dbpool.QueryRow(ctx, query, deckID).Scan(
+			&deck.ID, &deck.Name, &deck.Description, &deck.Format, &deck.UserID, &deck.IsPublic, &deck.CreatedAt, &deck.UpdatedAt, &deck.ForkedFrom, &deck.Budget)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return deck, ErrDeckNotFound
+		}
+		return deck, err
+	}
+}
+
+// LoadDeck fetches a deck's metadata and checks that userID may use it.
+func LoadDeck(ctx context.Context, dbpool *pgxpool.Pool, deckID uuid.UUID, userID string, perm DeckPermission) (models.Deck, error) {
+	return loadDeck(ctx, fetchDeck(dbpool), deckID, userID, perm)
+}
+
+func loadDeck(ctx context.Context, fetch deckFetcher, deckID uuid.UUID, userID string, perm DeckPermission) (models.Deck, error) {
+	deck, err := fetch(ctx, deckID)
+	if err != nil {
+		return deck, err
+	}
+	if err := CheckDeckAccess(deck, userID, perm); err != nil {
+		return deck, err
+	}
+	return deck, nil
+}
+
+// DeckAccess loads the deck named by the :deckId route parameter once,
+// enforces the requested permission and stores the deck in the context
+// for the handler. Use CurrentDeck to read it back.
+func DeckAccess(dbpool *pgxpool.Pool, perm DeckPermission) gin.HandlerFunc {
+	return deckAccess(fetchDeck(dbpool), perm)
+}
+
+func deckAccess(fetch deckFetcher, perm DeckPermission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID, err := uuid.Parse(c.Param("deckId"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID format"})
+			return
+		}
+
+		deck, err := loadDeck(context.Background(), fetch, deckID, c.GetString("userID"), perm)
+		if err != nil {
+			AbortWithDeckError(c, err)
+			return
+		}
+
+		c.Set("deck", deck)
+		c.Next()
+	}
+}
+
+// CurrentDeck returns the deck stored by DeckAccess.
+func CurrentDeck(c *gin.Context) models.Deck {
+	return c.MustGet("deck").(models.Deck)
+}
+
+// AbortWithDeckError maps an error from LoadDeck to a consistent response.
+func AbortWithDeckError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrDeckNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+	case errors.Is(err, ErrDeckForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this deck"})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load deck"})
+	}
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mana-tomb/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+)
+
+var (
+	ownerID   = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	otherID   = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	privateID = uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	publicID  = uuid.MustParse("00000000-0000-0000-0000-0000000000a2")
+	unknownID = uuid.MustParse("00000000-0000-0000-0000-0000000000ff")
+)
+
+// fakeDecks serves two decks owned by ownerID, one private and one public.
+func fakeDecks(ctx context.Context, deckID uuid.UUID) (models.Deck, error) {
+	switch deckID {
+	case privateID:
+		return models.Deck{ID: privateID, UserID: ownerID}, nil
+	case publicID:
+		return models.Deck{ID: publicID, UserID: ownerID, IsPublic: true}, nil
+	}
+	return models.Deck{}, ErrDeckNotFound
+}
+
+// newTestRouter mounts reads and writes the way main.go does: reads allow
+// anonymous visitors, writes require a session.
+func newTestRouter(store *sessions.CookieStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": CurrentDeck(c).ID})
+	}
+	r.GET("/decks/:deckId", OptionalAuth(store), deckAccess(fakeDecks, DeckRead), ok)
+	r.PUT("/decks/:deckId", AuthRequired(store), deckAccess(fakeDecks, DeckWrite), ok)
+	return r
+}
+
+// sessionCookie logs userID in through the store, as LoginUser does.
+func sessionCookie(t *testing.T, store *sessions.CookieStore, userID uuid.UUID) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "mana-tomb-session")
+	if err != nil {
+		t.Fatalf("getting session: %v", err)
+	}
+	session.Values["user_id"] = userID.String()
+	if err := session.Save(req, rec); err != nil {
+		t.Fatalf("saving session: %v", err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d session cookies, want 1", len(cookies))
+	}
+	return cookies[0]
+}
+
+func TestDeckAccess(t *testing.T) {
+	store := sessions.NewCookieStore([]byte("test-session-secret"))
+	router := newTestRouter(store)
+
+	tests := []struct {
+		name   string
+		method string
+		deckID uuid.UUID
+		user   *uuid.UUID
+		want   int
+	}{
+		{"owner reads private deck", http.MethodGet, privateID, &ownerID, http.StatusOK},
+		{"owner writes private deck", http.MethodPut, privateID, &ownerID, http.StatusOK},
+		{"owner writes public deck", http.MethodPut, publicID, &ownerID, http.StatusOK},
+		{"other user reads private deck", http.MethodGet, privateID, &otherID, http.StatusForbidden},
+		{"other user writes private deck", http.MethodPut, privateID, &otherID, http.StatusForbidden},
+		{"other user reads public deck", http.MethodGet, publicID, &otherID, http.StatusOK},
+		{"other user writes public deck", http.MethodPut, publicID, &otherID, http.StatusForbidden},
+		{"anonymous reads public deck", http.MethodGet, publicID, nil, http.StatusOK},
+		{"anonymous reads private deck", http.MethodGet, privateID, nil, http.StatusForbidden},
+		{"anonymous writes public deck", http.MethodPut, publicID, nil, http.StatusUnauthorized},
+		{"owner reads unknown deck", http.MethodGet, unknownID, &ownerID, http.StatusNotFound},
+		{"anonymous reads unknown deck", http.MethodGet, unknownID, nil, http.StatusNotFound},
+		{"owner writes unknown deck", http.MethodPut, unknownID, &ownerID, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/decks/"+tt.deckID.String(), nil)
+			if tt.user != nil {
+				req.AddCookie(sessionCookie(t, store, *tt.user))
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d (body %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeckAccessInvalidID(t *testing.T) {
+	router := newTestRouter(sessions.NewCookieStore([]byte("test-session-secret")))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/decks/not-a-uuid", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCheckDeckAccess(t *testing.T) {
+	private := models.Deck{UserID: ownerID}
+	public := models.Deck{UserID: ownerID, IsPublic: true}
+
+	tests := []struct {
+		name   string
+		deck   models.Deck
+		userID string
+		perm   DeckPermission
+		want   error
+	}{
+		{"owner reads", private, ownerID.String(), DeckRead, nil},
+		{"owner writes", private, ownerID.String(), DeckWrite, nil},
+		{"other reads private", private, otherID.String(), DeckRead, ErrDeckForbidden},
+		{"other writes private", private, otherID.String(), DeckWrite, ErrDeckForbidden},
+		{"other reads public", public, otherID.String(), DeckRead, nil},
+		{"other writes public", public, otherID.String(), DeckWrite, ErrDeckForbidden},
+		{"anonymous reads public", public, "", DeckRead, nil},
+		{"anonymous reads private", private, "", DeckRead, ErrDeckForbidden},
+		{"anonymous writes public", public, "", DeckWrite, ErrDeckForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckDeckAccess(tt.deck, tt.userID, tt.perm); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+// BracketEstimate places a Commander deck in a bracket of the Commander
+// bracket system, 1 (Exhibition) to 5 (cEDH), with the reasons why.
+type BracketEstimate struct {
+	Bracket int             `json:"bracket"`
+	Name    string          `json:"name"`
+	Reasons []BracketReason `json:"reasons"`
+}
+
+// BracketReason is one thing found in a deck and the lowest bracket it
+// fits in. Cards are the cards responsible.
+type BracketReason struct {
+	Rule        string   `json:"rule"`
+	Bracket     int      `json:"bracket"`
+	Explanation string   `json:"explanation"`
+	Cards       []string `json:"cards"`
+}
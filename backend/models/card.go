@@ -3,20 +3,40 @@ package models
 import (
 	"encoding/json"
 
+	"mana-tomb/backend/manacost"
+
 	"github.com/google/uuid"
 )
 
 // Card represents the data for a single Magic: The Gathering card
 // that we cache in our database from Scryfall.
 type Card struct {
-	ScryfallID    uuid.UUID       `json:"id"` // Note: This is the Scryfall ID
-	Name          string          `json:"name"`
-	ImageURIs     json.RawMessage `json:"image_uris"`
-	ManaCost      string          `json:"mana_cost"`
-	CMC           float32         `json:"cmc"`
-	TypeLine      string          `json:"type_line"`
-	OracleText    string          `json:"oracle_text"`
-	Colors        []string        `json:"colors"`
-	ColorIdentity []string        `json:"color_identity"`
-	Quantity      int             `json:"quantity,omitempty"` // Used when returning cards in a deck
+	ScryfallID      uuid.UUID       `json:"id"` // Note: This is the Scryfall ID
+	Name            string          `json:"name"`
+	ImageURIs       json.RawMessage `json:"image_uris"`
+	ManaCost        string          `json:"mana_cost"`
+	CMC             float32         `json:"cmc"`
+	TypeLine        string          `json:"type_line"`
+	OracleText      string          `json:"oracle_text"`
+	Colors          []string        `json:"colors"`
+	ColorIdentity   []string        `json:"color_identity"`
+	SetCode         string          `json:"set"`
+	CollectorNumber string          `json:"collector_number"`
+	Quantity        int             `json:"quantity,omitempty"` // Used when returning cards in a deck
+	// Tags are the card's functional tags in a deck, overrides applied, and
+	// TagOverrides the owner's corrections to the automatic ones.
+	Tags         []string        `json:"tags,omitempty"`
+	TagOverrides map[string]bool `json:"tag_overrides,omitempty"`
+	// CustomTags, Note and SortOrder are what the owner put on the entry.
+	CustomTags []string `json:"custom_tags,omitempty"`
+	Note       string   `json:"note,omitempty"`
+	SortOrder  *int     `json:"sort_order,omitempty"`
+}
+
+// Identity returns the card's color identity, worked out from its mana cost
+// and rules text. The identity Scryfall reports is added in: it covers color
+// indicators and the back faces of transforming cards, whose costs and
+// colors are not stored.
+func (c Card) Identity() []string {
+	return manacost.ColorIdentity(c.ManaCost, c.OracleText, c.ColorIdentity)
 }
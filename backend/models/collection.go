@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection is a named group of cards a user owns.
+type Collection struct {
+	ID        uuid.UUID        `json:"id"`
+	UserID    uuid.UUID        `json:"user_id"`
+	Name      string           `json:"name"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	CardCount int              `json:"card_count"`
+	Cards     []CollectionCard `json:"cards,omitempty"`
+}
+
+// CollectionCard is a stack of identical owned copies of a card.
+type CollectionCard struct {
+	ID        uuid.UUID `json:"id"`
+	Card      Card      `json:"card"`
+	Quantity  int       `json:"quantity"`
+	Foil      bool      `json:"foil"`
+	Condition string    `json:"condition"`
+	Language  string    `json:"language"`
+}
+
+// OwnershipReport compares a deck against the current user's collections.
+type OwnershipReport struct {
+	DeckID  uuid.UUID       `json:"deck_id"`
+	Needed  int             `json:"needed"`
+	Owned   int             `json:"owned"`
+	Missing int             `json:"missing"`
+	Cards   []CardOwnership `json:"cards"`
+}
+
+// CardOwnership describes how many copies of a card the deck needs and how
+// many the user has. Printings are interchangeable, so cards are matched by
+// name. Available is what is left after the user's other decks take their
+// copies.
+type CardOwnership struct {
+	Name      string           `json:"name"`
+	Needed    int              `json:"needed"`
+	Owned     int              `json:"owned"`
+	Available int              `json:"available"`
+	Missing   int              `json:"missing"`
+	Committed []DeckCommitment `json:"committed"`
+}
+
+// DeckCommitment is a number of copies of a card used by another deck.
+type DeckCommitment struct {
+	DeckID   uuid.UUID `json:"deck_id"`
+	DeckName string    `json:"deck_name"`
+	Quantity int       `json:"quantity"`
+}
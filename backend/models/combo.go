@@ -0,0 +1,28 @@
+package models
+
+import "github.com/google/uuid"
+
+// Combo is a set of cards that together win the game or do something
+// unbounded, such as making infinite mana. Prerequisites describe the
+// board state the combo needs beyond its cards.
+type Combo struct {
+	ID            string   `json:"id"`
+	Cards         []string `json:"cards"`
+	Prerequisites []string `json:"prerequisites"`
+	Results       []string `json:"results"`
+}
+
+// ComboMatch is a combo found in a deck. Missing names the one card the
+// deck lacks when the combo is not complete.
+type ComboMatch struct {
+	Combo
+	Missing string `json:"missing,omitempty"`
+}
+
+// DeckCombos lists the combos in a deck's commander zone and mainboard,
+// and those it is one card away from.
+type DeckCombos struct {
+	DeckID      uuid.UUID    `json:"deck_id"`
+	Complete    []ComboMatch `json:"complete"`
+	OneCardAway []ComboMatch `json:"one_card_away"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "github.com/google/uuid"
+
+// DeckComparison describes how two decks differ. Cards are matched by
+// name, so different printings of the same card count as shared.
+type DeckComparison struct {
+	A       DeckSummary  `json:"a"`
+	B       DeckSummary  `json:"b"`
+	OnlyInA []BoardCard  `json:"only_in_a"`
+	OnlyInB []BoardCard  `json:"only_in_b"`
+	Shared  []SharedCard `json:"shared"`
+	Delta   SummaryDelta `json:"delta"`
+}
+
+// DeckSummary holds the headline numbers of one side of a comparison. They
+// cover the commander zone and the mainboard.
+type DeckSummary struct {
+	ID                uuid.UUID      `json:"id"`
+	Name              string         `json:"name"`
+	UserID            uuid.UUID      `json:"user_id"`
+	CardCount         int            `json:"card_count"`
+	AverageCMC        float64        `json:"average_cmc"`
+	ColorDistribution map[string]int `json:"color_distribution"`
+}
+
+// SummaryDelta is deck B's summary minus deck A's.
+type SummaryDelta struct {
+	CardCount         int            `json:"card_count"`
+	AverageCMC        float64        `json:"average_cmc"`
+	ColorDistribution map[string]int `json:"color_distribution"`
+}
+
+// BoardCard is a card with its quantity on one board.
+type BoardCard struct {
+	Name     string `json:"name"`
+	Board    string `json:"board"`
+	Quantity int    `json:"quantity"`
+}
+
+// SharedCard is a card both decks play, with its quantities per board.
+type SharedCard struct {
+	Name   string          `json:"name"`
+	Boards []BoardQuantity `json:"boards"`
+}
+
+// BoardQuantity compares the copies of a card on one board of each deck.
+type BoardQuantity struct {
+	Board      string `json:"board"`
+	QuantityA  int    `json:"quantity_a"`
+	QuantityB  int    `json:"quantity_b"`
+	Difference int    `json:"difference"`
+}
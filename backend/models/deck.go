@@ -6,16 +6,65 @@ import (
 	"github.com/google/uuid"
 )
 
-// The Deck struct is updated to hold separate slices for different boards.
-// This makes it easier to send structured data to the frontend.
+// Deck holds a deck's metadata and, when loaded, its cards grouped by board.
+//
+// Commanders and Companion mirror the commander and companion boards for
+// convenience. The deck's color identity is derived from the commanders.
 type Deck struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Format      string    `json:"format"`
-	UserID      uuid.UUID `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Mainboard   []Card    `json:"mainboard,omitempty"`
-	Maybeboard  []Card    `json:"maybeboard,omitempty"`
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Format        string     `json:"format"`
+	UserID        uuid.UUID  `json:"user_id"`
+	IsPublic      bool       `json:"is_public"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ForkedFrom    *uuid.UUID `json:"forked_from,omitempty"`
+	ForkCount     int        `json:"fork_count,omitempty"`
+	Budget        *float64   `json:"budget,omitempty"` // Price ceiling in US dollars
+	Commanders    []Card     `json:"commanders,omitempty"`
+	Companion     *Card      `json:"companion,omitempty"`
+	ColorIdentity []string   `json:"color_identity,omitempty"`
+	Boards        []Board    `json:"boards,omitempty"`
+	// TagCounts counts the copies in the commander zone and mainboard
+	// carrying each functional tag.
+	TagCounts map[string]int `json:"tag_counts,omitempty"`
+	// Bracket is the estimated Commander bracket of Commander decks.
+	Bracket *BracketEstimate `json:"bracket,omitempty"`
+}
+
+// Board is one zone of a deck, either built in or created by the owner.
+type Board struct {
+	Name   string `json:"name"`
+	Label  string `json:"label"`
+	Custom bool   `json:"custom"`
+	Cards  []Card `json:"cards"`
+}
+
+// Cards returns the cards on the named board, or nil if the deck has no such board.
+func (d *Deck) Cards(board string) []Card {
+	for _, b := range d.Boards {
+		if b.Name == board {
+			return b.Cards
+		}
+	}
+	return nil
+}
+
+// SetCommanders stores the commander zone and recomputes the deck's color
+// identity from it, in WUBRG order.
+func (d *Deck) SetCommanders(commanders []Card) {
+	d.Commanders = commanders
+	seen := make(map[string]bool)
+	for _, commander := range commanders {
+		for _, color := range commander.Identity() {
+			seen[color] = true
+		}
+	}
+	d.ColorIdentity = make([]string, 0, len(seen))
+	for _, color := range []string{"W", "U", "B", "R", "G"} {
+		if seen[color] {
+			d.ColorIdentity = append(d.ColorIdentity, color)
+		}
+	}
 }
@@ -0,0 +1,50 @@
+package models
+
+import "github.com/google/uuid"
+
+// HealthTemplate is a target makeup for a deck. Built-in templates have a
+// short name as their ID; users' own templates have a UUID.
+type HealthTemplate struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Format  string         `json:"format"`
+	Builtin bool           `json:"builtin"`
+	Targets []HealthTarget `json:"targets"`
+}
+
+// HealthTarget is how many cards of a category a template asks for. The
+// categories are those of the draw odds: "lands", "nonlands",
+// "type:<type>" and "tag:<tag>".
+type HealthTarget struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// DeckHealth compares a deck with a template.
+type DeckHealth struct {
+	DeckID     uuid.UUID    `json:"deck_id"`
+	Format     string       `json:"format"`
+	Template   TemplateRef  `json:"template"`
+	Cards      int          `json:"cards"`
+	Healthy    bool         `json:"healthy"`
+	Lines      []HealthLine `json:"lines"`
+	Shortfalls []HealthLine `json:"shortfalls"`
+	Surpluses  []HealthLine `json:"surpluses"`
+}
+
+// TemplateRef names the template a deck was checked against.
+type TemplateRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// HealthLine is one target of a template next to the deck's count.
+// Difference is the count less the target: negative for a shortfall,
+// positive for a surplus.
+type HealthLine struct {
+	Category   string `json:"category"`
+	Target     int    `json:"target"`
+	Count      int    `json:"count"`
+	Difference int    `json:"difference"`
+	Status     string `json:"status"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "github.com/google/uuid"
+
+// ImportLine reports what happened to a single line of an imported decklist.
+// Status is one of "matched", "ambiguous", "unresolved" or "invalid".
+type ImportLine struct {
+	Line       int        `json:"line"`
+	Text       string     `json:"text"`
+	Status     string     `json:"status"`
+	Quantity   int        `json:"quantity"`
+	Board      string     `json:"board,omitempty"`
+	CardID     *uuid.UUID `json:"card_id,omitempty"`
+	CardName   string     `json:"card_name,omitempty"`
+	Candidates []string   `json:"candidates,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+// ImportReport summarises a decklist import. Only matched lines are
+// added to the deck or collection; the rest are listed so the user can
+// fix them.
+type ImportReport struct {
+	Matched    int          `json:"matched"`
+	Ambiguous  int          `json:"ambiguous"`
+	Unresolved int          `json:"unresolved"`
+	Invalid    int          `json:"invalid"`
+	CardsAdded int          `json:"cards_added"`
+	Lines      []ImportLine `json:"lines"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "github.com/google/uuid"
+
+// DrawOdds is the chance of drawing at least AtLeast of the matching cards
+// by a given turn. Library is the number of cards drawn from; in Commander
+// that is the 99 cards outside the command zone.
+type DrawOdds struct {
+	DeckID      uuid.UUID  `json:"deck_id"`
+	Library     int        `json:"library"`
+	Matching    int        `json:"matching"`
+	Cards       []string   `json:"cards"`
+	AtLeast     int        `json:"at_least"`
+	Turn        int        `json:"turn"`
+	OnTheDraw   bool       `json:"on_the_draw"`
+	CardsSeen   int        `json:"cards_seen"`
+	Probability float64    `json:"probability"`
+	ByTurn      []TurnOdds `json:"by_turn"`
+}
+
+// TurnOdds is the chance of having drawn the cards by one turn.
+type TurnOdds struct {
+	Turn        int     `json:"turn"`
+	CardsSeen   int     `json:"cards_seen"`
+	Probability float64 `json:"probability"`
+}
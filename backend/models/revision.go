@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision is one recorded change to a deck.
+type Revision struct {
+	Revision  int              `json:"revision"`
+	UserID    *uuid.UUID       `json:"user_id"`
+	Username  *string          `json:"username"`
+	Action    string           `json:"action"`
+	Summary   string           `json:"summary"`
+	CreatedAt time.Time        `json:"created_at"`
+	Changes   []RevisionChange `json:"changes"`
+}
+
+// RevisionChange is the change in the number of copies of a card on a board.
+type RevisionChange struct {
+	CardID uuid.UUID `json:"card_id"`
+	Name   string    `json:"name"`
+	Board  string    `json:"board"`
+	Delta  int       `json:"delta"`
+}
+
+// DeckDiff lists the card changes between two revisions of a deck.
+type DeckDiff struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Added   []RevisionChange `json:"added"`
+	Removed []RevisionChange `json:"removed"`
+}
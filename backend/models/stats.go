@@ -0,0 +1,33 @@
+package models
+
+import "github.com/google/uuid"
+
+// DeckStats describes the cards in a deck's commander zone and mainboard.
+//
+// AverageCMC leaves out lands, as deckbuilders usually do. Pips counts the
+// colored symbols in the mana costs of nonland cards; a hybrid symbol counts
+// towards both of its colors. LandSources counts, per color, the lands that
+// can produce it, with "C" for colorless. Devotion is the deck's devotion
+// to each color if every permanent were on the battlefield.
+type DeckStats struct {
+	DeckID        uuid.UUID      `json:"deck_id"`
+	CardCount     int            `json:"card_count"`
+	Lands         int            `json:"lands"`
+	Nonlands      int            `json:"nonlands"`
+	AverageCMC    float64        `json:"average_cmc"`
+	ManaCurve     []CurveBucket  `json:"mana_curve"`
+	Types         map[string]int `json:"types"`
+	Pips          map[string]int `json:"pips"`
+	HybridPips    int            `json:"hybrid_pips"`
+	PhyrexianPips int            `json:"phyrexian_pips"`
+	LandSources   map[string]int `json:"land_sources"`
+	Devotion      map[string]int `json:"devotion"`
+}
+
+// CurveBucket counts the cards of one mana value. The last bucket, labelled
+// "7+", holds everything from 7 up.
+type CurveBucket struct {
+	CMC      string `json:"cmc"`
+	Nonlands int    `json:"nonlands"`
+	Lands    int    `json:"lands"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// TagGroup is the cards of a deck that carry one tag. Kind is "custom" for
+// the owner's own tags, "functional" for the automatic ones and empty for
+// the group of cards with no tag at all.
+type TagGroup struct {
+	Tag   string       `json:"tag"`
+	Label string       `json:"label"`
+	Kind  string       `json:"kind"`
+	Count int          `json:"count"`
+	Cards []TaggedCard `json:"cards"`
+}
+
+// TaggedCard is a card in a tag group, along with the board it is on.
+type TaggedCard struct {
+	Card
+	Board string `json:"board"`
+}
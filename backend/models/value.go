@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeckValue is what a deck's cards are worth in one currency. Total counts
+// the boards that need real copies; maybeboard-style boards are priced in
+// Boards but left out of the total. Budget and OverBudget are only filled
+// in for US dollars, the currency budgets are kept in.
+type DeckValue struct {
+	DeckID          uuid.UUID    `json:"deck_id"`
+	Currency        string       `json:"currency"`
+	Total           float64      `json:"total"`
+	Budget          *float64     `json:"budget,omitempty"`
+	OverBudget      bool         `json:"over_budget"`
+	PricesUpdatedOn *time.Time   `json:"prices_updated_on,omitempty"`
+	Boards          []BoardValue `json:"boards"`
+	MostExpensive   []CardValue  `json:"most_expensive"`
+	Unpriced        []string     `json:"unpriced"`
+}
+
+// BoardValue totals one board. Unpriced counts copies without a price.
+type BoardValue struct {
+	Board    string  `json:"board"`
+	Total    float64 `json:"total"`
+	Cards    int     `json:"cards"`
+	Unpriced int     `json:"unpriced"`
+}
+
+// CardValue is the price of one card on a board.
+type CardValue struct {
+	CardID   uuid.UUID `json:"card_id"`
+	Name     string    `json:"name"`
+	Board    string    `json:"board"`
+	Quantity int       `json:"quantity"`
+	Price    float64   `json:"price"`
+	Total    float64   `json:"total"`
+}
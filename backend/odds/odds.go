@@ -0,0 +1,55 @@
+// Package odds computes the chance of drawing cards from a library, using
+// the hypergeometric distribution.
+package odds
+
+import "math"
+
+// OpeningHand is the number of cards in an opening hand.
+const OpeningHand = 7
+
+// CardsSeen returns how many cards a player has seen by the given turn:
+// the opening hand plus one draw per turn. The player on the play skips
+// the draw of their first turn.
+func CardsSeen(turn int, onTheDraw bool) int {
+	seen := OpeningHand + turn
+	if !onTheDraw {
+		seen--
+	}
+	return seen
+}
+
+// AtLeast returns the probability of drawing at least k of the successes
+// when drawing draws cards from a library of the given size.
+func AtLeast(library, successes, draws, k int) float64 {
+	if k <= 0 {
+		return 1
+	}
+	if successes < k || draws < k || library <= 0 {
+		return 0
+	}
+	draws = min(draws, library)
+
+	total := 0.0
+	for i := k; i <= min(successes, draws); i++ {
+		total += Exactly(library, successes, draws, i)
+	}
+	return math.Min(total, 1)
+}
+
+// Exactly returns the probability of drawing exactly k of the successes.
+// Drawing more cards than the library holds draws all of it.
+func Exactly(library, successes, draws, k int) float64 {
+	draws = min(draws, library)
+	if k < 0 || k > successes || k > draws || draws-k > library-successes {
+		return 0
+	}
+	return math.Exp(logChoose(successes, k) + logChoose(library-successes, draws-k) - logChoose(library, draws))
+}
+
+// logChoose returns the natural logarithm of n choose k.
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,45 @@
+package odds
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHypergeometric(t *testing.T) {
+	tests := []struct {
+		name                         string
+		library, successes, draws, k int
+		wantExactly, wantAtLeast     float64
+	}{
+		{"one of four in an opening hand", 60, 4, 7, 1, 0.336280, 0.399500},
+		{"three lands of 24", 60, 24, 7, 3, 0.308704, 0.587929},
+		{"three lands of 37 in 99", 99, 37, 7, 3, 0.291156, 0.524684},
+		{"no lands in ten cards", 40, 17, 10, 0, 0.001350, 1},
+		{"a single tutor target by turn one", 99, 1, 8, 1, 0.080808, 0.080808},
+		{"more successes wanted than exist", 60, 2, 7, 3, 0, 0},
+		{"more successes wanted than draws", 60, 20, 2, 3, 0, 0},
+		{"draws > library finds every success", 10, 3, 15, 3, 1, 1},
+		{"draws > library finds no more than exist", 10, 3, 15, 4, 0, 0},
+		{"draws > library leaves nothing behind", 10, 3, 15, 2, 0, 1},
+		{"empty library", 0, 0, 7, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Exactly(tt.library, tt.successes, tt.draws, tt.k); math.Abs(got-tt.wantExactly) > 1e-6 {
+				t.Errorf("Exactly = %.6f, want %.6f", got, tt.wantExactly)
+			}
+			if got := AtLeast(tt.library, tt.successes, tt.draws, tt.k); math.Abs(got-tt.wantAtLeast) > 1e-6 {
+				t.Errorf("AtLeast = %.6f, want %.6f", got, tt.wantAtLeast)
+			}
+		})
+	}
+}
+
+func TestCardsSeen(t *testing.T) {
+	if got := CardsSeen(1, false); got != 7 {
+		t.Errorf("turn 1 on the play: got %d, want 7", got)
+	}
+	if got := CardsSeen(3, true); got != 10 {
+		t.Errorf("turn 3 on the draw: got %d, want 10", got)
+	}
+}
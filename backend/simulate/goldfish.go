@@ -0,0 +1,397 @@
+package simulate
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/manacost"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/stats"
+)
+
+// MaxGoldfishTurns caps how many turns a goldfish game is played for.
+const MaxGoldfishTurns = 20
+
+// GoldfishOptions configure a goldfish simulation.
+type GoldfishOptions struct {
+	Turns     int
+	Trials    int
+	Seed      int64
+	OnTheDraw bool
+	// Commanders are checked for being castable each turn. They are never
+	// cast, so the mana stays for ramp.
+	Commanders []models.Card
+}
+
+// GoldfishReport summarizes a goldfish simulation. Expected values are
+// averages over the trials and rates are fractions of them.
+type GoldfishReport struct {
+	Seed       int64            `json:"seed"`
+	Trials     int              `json:"trials"`
+	Library    int              `json:"library"`
+	OnTheDraw  bool             `json:"on_the_draw"`
+	ByTurn     []TurnMana       `json:"by_turn"`
+	Commanders []CommanderCurve `json:"commanders"`
+}
+
+// TurnMana is what the board looks like in one turn's main phase, after
+// the land drop and any ramp.
+type TurnMana struct {
+	Turn          int     `json:"turn"`
+	ExpectedMana  float64 `json:"expected_mana"`
+	ExpectedLands float64 `json:"expected_lands"`
+	// LandDropRate is how often a land was played that turn.
+	LandDropRate float64 `json:"land_drop_rate"`
+}
+
+// CommanderCurve is how soon a commander could be cast. The on-curve turn
+// is the turn matching its mana value.
+type CommanderCurve struct {
+	Name        string     `json:"name"`
+	ManaCost    string     `json:"mana_cost"`
+	ManaValue   float64    `json:"mana_value"`
+	OnCurveTurn int        `json:"on_curve_turn"`
+	OnCurveRate float64    `json:"on_curve_rate"`
+	CastableBy  []TurnRate `json:"castable_by"`
+}
+
+// TurnRate is how often something had happened by a turn.
+type TurnRate struct {
+	Turn int     `json:"turn"`
+	Rate float64 `json:"rate"`
+}
+
+// Goldfish plays the library against an empty board. Each turn it draws,
+// plays the best land in hand and then casts what ramp it can afford,
+// cheapest first: mana rocks, mana creatures, which cannot tap the turn
+// they arrive, and spells that put lands onto the battlefield. Only simple
+// "{T}: Add" abilities count as mana; everything else in the deck is a
+// blank.
+func Goldfish(library []models.Card, opts GoldfishOptions) GoldfishReport {
+	rng := newRand(opts.Seed)
+	report := GoldfishReport{Seed: opts.Seed, Trials: opts.Trials, Library: len(library), OnTheDraw: opts.OnTheDraw}
+
+	commanders := make([]goldfishCommander, 0, len(opts.Commanders))
+	for _, card := range opts.Commanders {
+		cost, err := manacost.Parse(card.ManaCost)
+		if err != nil || card.ManaCost == "" {
+			continue
+		}
+		mv := cost.ManaValue()
+		commanders = append(commanders, goldfishCommander{
+			card:    card,
+			cost:    cost,
+			onCurve: max(1, int(math.Ceil(mv))),
+		})
+	}
+
+	// Play on past the turns asked for if a commander's curve needs it.
+	turns := opts.Turns
+	for _, cmd := range commanders {
+		turns = max(turns, cmd.onCurve)
+	}
+	turns = min(turns, MaxGoldfishTurns)
+
+	report.ByTurn = make([]TurnMana, 0, opts.Turns)
+	report.Commanders = make([]CommanderCurve, 0, len(commanders))
+	if opts.Trials <= 0 || len(library) == 0 || turns <= 0 {
+		return report
+	}
+
+	cards := make([]goldfishCard, len(library))
+	for i, card := range library {
+		cards[i] = describeForGoldfish(card)
+	}
+
+	mana := make([]int, turns+1)
+	lands := make([]int, turns+1)
+	landDrops := make([]int, turns+1)
+	castable := make([][]int, len(commanders))
+	for i := range castable {
+		castable[i] = make([]int, turns+1)
+	}
+
+	order := make([]int, len(library))
+	for i := range order {
+		order[i] = i
+	}
+	for trial := 0; trial < opts.Trials; trial++ {
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		game := &goldfishGame{cards: cards}
+		game.hand = append(game.hand, order[:min(HandSize, len(order))]...)
+		game.library = append(game.library, order[len(game.hand):]...)
+		firstCastable := make([]int, len(commanders))
+
+		for turn := 1; turn <= turns; turn++ {
+			if turn > 1 || opts.OnTheDraw {
+				game.draw()
+			}
+			for i := range game.sources {
+				game.sources[i].ready = true
+			}
+			if game.playLand() {
+				landDrops[turn]++
+			}
+
+			units := game.pool()
+			available := len(units)
+			checkCommanders(commanders, units, firstCastable, turn)
+			units, added := game.castRamp(units)
+			available += added
+			checkCommanders(commanders, units, firstCastable, turn)
+
+			mana[turn] += available
+			lands[turn] += game.lands
+		}
+		for i, first := range firstCastable {
+			if first > 0 {
+				castable[i][first]++
+			}
+		}
+	}
+
+	trials := float64(opts.Trials)
+	for turn := 1; turn <= min(opts.Turns, turns); turn++ {
+		report.ByTurn = append(report.ByTurn, TurnMana{
+			Turn:          turn,
+			ExpectedMana:  round4(float64(mana[turn]) / trials),
+			ExpectedLands: round4(float64(lands[turn]) / trials),
+			LandDropRate:  round4(float64(landDrops[turn]) / trials),
+		})
+	}
+	for i, cmd := range commanders {
+		curve := CommanderCurve{
+			Name:        cmd.card.Name,
+			ManaCost:    cmd.card.ManaCost,
+			ManaValue:   cmd.cost.ManaValue(),
+			OnCurveTurn: cmd.onCurve,
+			CastableBy:  make([]TurnRate, 0, turns),
+		}
+		by := 0
+		for turn := 1; turn <= turns; turn++ {
+			by += castable[i][turn]
+			rate := round4(float64(by) / trials)
+			curve.CastableBy = append(curve.CastableBy, TurnRate{Turn: turn, Rate: rate})
+			if turn == cmd.onCurve {
+				curve.OnCurveRate = rate
+			}
+		}
+		report.Commanders = append(report.Commanders, curve)
+	}
+	return report
+}
+
+type goldfishCommander struct {
+	card    models.Card
+	cost    manacost.Cost
+	onCurve int
+}
+
+// checkCommanders records the turn each commander first became castable
+// with the mana in units.
+func checkCommanders(commanders []goldfishCommander, units []colorMask, first []int, turn int) {
+	for i, cmd := range commanders {
+		if first[i] > 0 {
+			continue
+		}
+		if _, ok := pay(units, cmd.cost); ok {
+			first[i] = turn
+		}
+	}
+}
+
+// goldfishCard holds what the goldfish needs to know about one card of the
+// library, worked out once rather than on every turn.
+type goldfishCard struct {
+	land      bool
+	basic     bool
+	basicType bool
+	creature  bool
+	tapped    bool
+
+	producer  producer
+	produces  bool
+	fetch     landFetch
+	fetches   bool
+	cost      manacost.Cost
+	manaValue float64
+	// ramp is set for nonland cards worth casting in a goldfish.
+	ramp bool
+}
+
+func describeForGoldfish(card models.Card) goldfishCard {
+	front, _, _ := strings.Cut(card.TypeLine, " // ")
+	info := goldfishCard{
+		land:      stats.IsLand(card),
+		basic:     strings.Contains(front, "Basic"),
+		basicType: hasBasicLandType(card),
+		creature:  strings.Contains(front, "Creature"),
+		tapped:    entersTapped(card),
+	}
+	info.producer, info.produces = manaProducer(card)
+	info.fetch, info.fetches = fetchesLand(card)
+	if info.land || card.ManaCost == "" {
+		return info
+	}
+	cost, err := manacost.Parse(card.ManaCost)
+	if err != nil {
+		return info
+	}
+	info.cost, info.manaValue = cost, cost.ManaValue()
+	info.ramp = info.produces || info.fetches
+	return info
+}
+
+// source is a permanent that makes mana.
+type source struct {
+	producer producer
+	ready    bool
+}
+
+// goldfishGame is one game in progress. hand and library hold indexes into
+// cards; the top of the library is its first entry.
+type goldfishGame struct {
+	cards   []goldfishCard
+	hand    []int
+	library []int
+	sources []source
+	lands   int
+}
+
+func (g *goldfishGame) draw() {
+	if len(g.library) > 0 {
+		g.hand = append(g.hand, g.library[0])
+		g.library = g.library[1:]
+	}
+}
+
+// playLand plays the land in hand that gives the most this turn: untapped
+// before tapped, then the one making the most colors.
+func (g *goldfishGame) playLand() bool {
+	best, bestScore := -1, -1
+	for i, c := range g.hand {
+		info := g.cards[c]
+		if !info.land {
+			continue
+		}
+		score := 0
+		switch {
+		case info.fetches:
+			if !info.fetch.tapped {
+				score = 10
+			}
+			score += 5
+		case info.produces:
+			if !info.tapped {
+				score = 10
+			}
+			score += bits.OnesCount8(uint8(info.producer.mask))
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return false
+	}
+
+	info := g.cards[g.hand[best]]
+	g.hand = append(g.hand[:best], g.hand[best+1:]...)
+	switch {
+	case info.fetches:
+		// The fetch land goes to the graveyard for the land it finds.
+		g.fetchLand(info.fetch)
+	case info.produces:
+		g.sources = append(g.sources, source{producer: info.producer, ready: !info.tapped})
+		g.lands++
+	default:
+		g.lands++
+	}
+	return true
+}
+
+// fetchLand moves the first land in the library that the search can find
+// onto the battlefield. It returns the mana the land adds this turn.
+func (g *goldfishGame) fetchLand(fetch landFetch) []colorMask {
+	for i, c := range g.library {
+		info := g.cards[c]
+		if !info.land || !info.produces || fetch.basic && !info.basic || !fetch.basic && !info.basicType {
+			continue
+		}
+		g.library = append(g.library[:i], g.library[i+1:]...)
+		ready := !fetch.tapped && !info.tapped
+		g.sources = append(g.sources, source{producer: info.producer, ready: ready})
+		g.lands++
+		if ready {
+			return info.producer.units()
+		}
+		return nil
+	}
+	return nil
+}
+
+// pool returns the mana the ready sources make, one mask per mana.
+func (g *goldfishGame) pool() []colorMask {
+	var units []colorMask
+	for _, s := range g.sources {
+		if s.ready {
+			units = append(units, s.producer.units()...)
+		}
+	}
+	return units
+}
+
+// castRamp casts the cheapest ramp in hand while the mana lasts. It returns
+// the mana left and how much the new permanents added this turn.
+func (g *goldfishGame) castRamp(units []colorMask) ([]colorMask, int) {
+	added := 0
+	for {
+		candidates := make([]int, 0, len(g.hand))
+		for i, c := range g.hand {
+			if g.cards[c].ramp {
+				candidates = append(candidates, i)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return g.cards[g.hand[candidates[i]]].manaValue < g.cards[g.hand[candidates[j]]].manaValue
+		})
+
+		cast := -1
+		for _, i := range candidates {
+			if rest, ok := pay(units, g.cards[g.hand[i]].cost); ok {
+				units, cast = rest, i
+				break
+			}
+		}
+		if cast < 0 {
+			return units, added
+		}
+
+		info := g.cards[g.hand[cast]]
+		g.hand = append(g.hand[:cast], g.hand[cast+1:]...)
+		if info.produces {
+			ready := !info.tapped && !info.creature
+			g.sources = append(g.sources, source{producer: info.producer, ready: false})
+			if ready {
+				units = append(units, info.producer.units()...)
+				added += info.producer.amount
+			}
+		}
+		if info.fetches {
+			fetched := g.fetchLand(info.fetch)
+			units = append(units, fetched...)
+			added += len(fetched)
+		}
+	}
+}
+
+// units returns the mana one activation makes, one mask per mana.
+func (p producer) units() []colorMask {
+	units := make([]colorMask, p.amount)
+	for i := range units {
+		units[i] = p.mask
+	}
+	return units
+}
@@ -0,0 +1,67 @@
+// Package simulate shuffles and plays out decks: sample hands, mulligans
+// and goldfish games. Every run is driven by a seed, so a result can be
+// shared and reproduced exactly.
+package simulate
+
+import (
+	"math/rand/v2"
+
+	"mana-tomb/backend/models"
+)
+
+// HandSize is the size of an opening hand.
+const HandSize = 7
+
+// maxSeed keeps generated seeds within the integers JavaScript clients can
+// represent exactly.
+const maxSeed = 1 << 53
+
+// NewSeed returns a random seed for callers that did not pick one.
+func NewSeed() int64 {
+	return rand.Int64N(maxSeed)
+}
+
+// newRand returns the random source for a seed.
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), 0))
+}
+
+// Library expands cards carrying quantities into one entry per copy, each
+// with a quantity of 1. The order is the order of cards.
+func Library(cards []models.Card) []models.Card {
+	var library []models.Card
+	for _, card := range cards {
+		copies := card.Quantity
+		card.Quantity = 1
+		for i := 0; i < copies; i++ {
+			library = append(library, card)
+		}
+	}
+	return library
+}
+
+// shuffle returns a shuffled copy of the library.
+func shuffle(library []models.Card, rng *rand.Rand) []models.Card {
+	deck := append([]models.Card(nil), library...)
+	rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+	return deck
+}
+
+// drawIndexes shuffles the first n positions of order, a permutation of
+// the library's indexes, and returns them. Only the cards drawn need to be
+// shuffled, which keeps many trials cheap.
+func drawIndexes(order []int, n int, rng *rand.Rand) []int {
+	n = min(n, len(order))
+	for i := 0; i < n; i++ {
+		j := i + rng.IntN(len(order)-i)
+		order[i], order[j] = order[j], order[i]
+	}
+	return append([]int(nil), order[:n]...)
+}
+
+// SampleHand shuffles the library with the given seed and returns the
+// opening hand.
+func SampleHand(library []models.Card, seed int64) []models.Card {
+	deck := shuffle(library, newRand(seed))
+	return deck[:min(HandSize, len(deck))]
+}
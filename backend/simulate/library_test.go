@@ -0,0 +1,52 @@
+package simulate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"mana-tomb/backend/models"
+)
+
+// testLibrary returns a 99-card library of 38 basic lands and 61 distinct
+// spells costing one to six.
+func testLibrary() []models.Card {
+	cards := []models.Card{{Name: "Forest", TypeLine: "Basic Land — Forest", Quantity: 38}}
+	for i := 0; i < 61; i++ {
+		mv := i%6 + 1
+		cards = append(cards, models.Card{
+			Name:     fmt.Sprintf("Spell %d", i),
+			TypeLine: "Creature — Elf",
+			ManaCost: fmt.Sprintf("{%d}{G}", mv-1),
+			CMC:      float32(mv),
+			Quantity: 1,
+		})
+	}
+	return Library(cards)
+}
+
+func TestSeedReproducesResults(t *testing.T) {
+	library := testLibrary()
+	opts := func(seed int64) MulliganOptions {
+		return MulliganOptions{Trials: 1000, Seed: seed, Keep: KeepRule{MinLands: 3, MaxLands: 4}, MinHandSize: 5}
+	}
+
+	tests := []struct {
+		name string
+		run  func(seed int64) any
+	}{
+		{"SampleHand", func(seed int64) any { return SampleHand(library, seed) }},
+		{"Mulligan", func(seed int64) any { return Mulligan(library, opts(seed)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, again := tt.run(42), tt.run(42)
+			if !reflect.DeepEqual(first, again) {
+				t.Errorf("seed 42 gave different results:\n%+v\n%+v", first, again)
+			}
+			if other := tt.run(43); reflect.DeepEqual(first, other) {
+				t.Errorf("seeds 42 and 43 gave the same result: %+v", first)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package simulate
+
+import (
+	"math/bits"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/manacost"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/stats"
+)
+
+// colorMask is a set of the mana types a source can make: one bit per
+// color in WUBRG order, then colorless.
+type colorMask uint8
+
+const colorless colorMask = 1 << 5
+
+const anyColor colorMask = 1<<5 - 1
+
+func maskOf(color string) colorMask {
+	for i, c := range manacost.Colors {
+		if c == color {
+			return 1 << i
+		}
+	}
+	if color == "C" {
+		return colorless
+	}
+	return 0
+}
+
+var (
+	manaAbilityPattern = regexp.MustCompile(`((?:\{[^{}]+\}, )*)\{T\}[^:.]*: Add ([^.]*)`)
+	manaSymbolPattern  = regexp.MustCompile(`\{([^{}]+)\}`)
+	manaCountPattern   = regexp.MustCompile(`\b(two|three|four|five) mana\b`)
+	numberWords        = map[string]int{"two": 2, "three": 3, "four": 4, "five": 5}
+	basicLandTypes     = map[string]string{"Plains": "W", "Island": "U", "Swamp": "B", "Mountain": "R", "Forest": "G"}
+)
+
+// producer describes a card's simple mana ability: tapping it adds amount
+// mana, each of any type in mask.
+type producer struct {
+	mask   colorMask
+	amount int
+}
+
+// manaProducer reads a card's "{T}: Add ..." abilities. Cards with several
+// such abilities, like pain lands, can make any type one of them makes.
+// Mana paid to activate an ability, as for Signets, is taken off what it
+// adds.
+func manaProducer(card models.Card) (producer, bool) {
+	var p producer
+	for _, m := range manaAbilityPattern.FindAllStringSubmatch(card.OracleText, -1) {
+		ability := strings.ToLower(m[2])
+		amount := 0
+		if strings.Contains(ability, "any color") || strings.Contains(ability, "any one color") || strings.Contains(ability, "any type") {
+			p.mask |= anyColor
+			amount = 1
+		}
+		symbols := manaSymbolPattern.FindAllStringSubmatch(ability, -1)
+		for _, s := range symbols {
+			p.mask |= maskOf(strings.ToUpper(s[1]))
+		}
+		switch {
+		case strings.Contains(ability, " or "):
+			// "{W}, {U}, or {B}" is one mana of a choice of types.
+			amount = max(amount, 1)
+		default:
+			// "{C}{C}" is two mana.
+			amount = max(amount, len(symbols))
+		}
+		if n := manaCountPattern.FindStringSubmatch(ability); n != nil {
+			amount = max(amount, numberWords[n[1]])
+		}
+		if activation, err := manacost.Parse(strings.TrimSuffix(m[1], ", ")); err == nil {
+			amount -= int(activation.ManaValue())
+		}
+		p.amount = max(p.amount, amount)
+	}
+
+	// Basic land types carry an intrinsic mana ability.
+	if stats.IsLand(card) {
+		_, subtypes, _ := strings.Cut(strings.SplitN(card.TypeLine, " // ", 2)[0], "—")
+		for _, w := range strings.Fields(subtypes) {
+			if color, ok := basicLandTypes[w]; ok {
+				p.mask |= maskOf(color)
+				p.amount = max(p.amount, 1)
+			}
+		}
+	}
+	return p, p.mask != 0 && p.amount > 0
+}
+
+// entersTapped reports whether a permanent always enters tapped. Lands that
+// enter tapped only under a condition are treated as untapped.
+func entersTapped(card models.Card) bool {
+	text := strings.ToLower(card.OracleText)
+	if !strings.Contains(text, "enters tapped") && !strings.Contains(text, "enters the battlefield tapped") {
+		return false
+	}
+	return !strings.Contains(text, "unless")
+}
+
+// landFetch describes a card that puts a land from the library onto the
+// battlefield, like Rampant Growth or Evolving Wilds.
+type landFetch struct {
+	// basic is set when only basic lands can be found.
+	basic bool
+	// tapped is set when the land arrives tapped.
+	tapped bool
+}
+
+// fetchesLand reads a card's text for a search that puts a land onto the
+// battlefield.
+func fetchesLand(card models.Card) (landFetch, bool) {
+	for _, sentence := range strings.Split(strings.ToLower(card.OracleText), ".") {
+		if strings.Contains(sentence, "search your library for") && namesLand(sentence) &&
+			strings.Contains(sentence, "onto the battlefield") {
+			return landFetch{basic: strings.Contains(sentence, "basic land"), tapped: strings.Contains(sentence, "tapped")}, true
+		}
+	}
+	return landFetch{}, false
+}
+
+// namesLand reports whether text mentions lands, either as such or by a
+// basic land type, as in "a Forest or Mountain card".
+func namesLand(text string) bool {
+	if strings.Contains(text, "land") {
+		return true
+	}
+	for landType := range basicLandTypes {
+		if strings.Contains(text, strings.ToLower(landType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBasicLandType reports whether a land has one of the basic land types,
+// as fetch lands and most ramp spells need.
+func hasBasicLandType(card models.Card) bool {
+	_, subtypes, _ := strings.Cut(strings.SplitN(card.TypeLine, " // ", 2)[0], "—")
+	for _, w := range strings.Fields(subtypes) {
+		if _, ok := basicLandTypes[w]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pay pays a cost from the mana units, one mask per mana, and returns the
+// units left over. Colored symbols are matched to units that can make them;
+// the least flexible of the rest pay the generic part. Phyrexian symbols are
+// paid with life and X is taken to be zero.
+func pay(units []colorMask, cost manacost.Cost) ([]colorMask, bool) {
+	var needs []colorMask
+	generic := 0
+	for _, s := range cost.Symbols {
+		switch {
+		case s.X:
+		case s.Phyrexian:
+		case len(s.Colors) > 0:
+			var m colorMask
+			for _, color := range s.Colors {
+				m |= maskOf(color)
+			}
+			needs = append(needs, m)
+		case s.Colorless:
+			needs = append(needs, colorless)
+		case s.Snow:
+			generic++
+		default:
+			generic += s.Generic
+		}
+	}
+	if len(needs)+generic > len(units) {
+		return units, false
+	}
+
+	owner, ok := matchAll(units, needs)
+	if !ok {
+		return units, false
+	}
+	var rest []colorMask
+	for u, mask := range units {
+		if owner[u] < 0 {
+			rest = append(rest, mask)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return bits.OnesCount8(uint8(rest[i])) < bits.OnesCount8(uint8(rest[j])) })
+	return rest[generic:], true
+}
+
+// matchAll finds a distinct unit for every need with augmenting paths. It
+// returns, per unit, the need it pays or -1.
+func matchAll(units []colorMask, needs []colorMask) ([]int, bool) {
+	owner := make([]int, len(units))
+	for i := range owner {
+		owner[i] = -1
+	}
+	var try func(need int, seen []bool) bool
+	try = func(need int, seen []bool) bool {
+		for u, mask := range units {
+			if seen[u] || mask&needs[need] == 0 {
+				continue
+			}
+			seen[u] = true
+			if owner[u] < 0 || try(owner[u], seen) {
+				owner[u] = need
+				return true
+			}
+		}
+		return false
+	}
+	for need := range needs {
+		if !try(need, make([]bool, len(units))) {
+			return owner, false
+		}
+	}
+	return owner, true
+}
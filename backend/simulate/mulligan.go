@@ -0,0 +1,253 @@
+package simulate
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/manacost"
+	"mana-tomb/backend/models"
+	"mana-tomb/backend/stats"
+)
+
+// KeepRule decides whether a hand is kept. A hand is kept when its land
+// count is within [MinLands, MaxLands] and it holds the must-have cards:
+// any one of them, or every one with MustHaveAll.
+type KeepRule struct {
+	MinLands    int
+	MaxLands    int
+	MustHave    []string
+	MustHaveAll bool
+}
+
+// MulliganOptions configure a mulligan simulation.
+type MulliganOptions struct {
+	Trials int
+	Seed   int64
+	Keep   KeepRule
+	// FreeMulligan makes the first mulligan free, as in multiplayer
+	// Commander: no card goes to the bottom for it.
+	FreeMulligan bool
+	// MinHandSize is the smallest hand a player mulligans to. That hand is
+	// kept whatever it holds.
+	MinHandSize int
+}
+
+// MulliganReport summarizes a mulligan simulation. Rates are fractions of
+// the trials.
+type MulliganReport struct {
+	Seed             int64          `json:"seed"`
+	Trials           int            `json:"trials"`
+	Library          int            `json:"library"`
+	KeptAt           []HandSizeRate `json:"kept_at"`
+	ForcedKeepRate   float64        `json:"forced_keep_rate"`
+	AverageMulligans float64        `json:"average_mulligans"`
+	OpeningLands     []LandRate     `json:"opening_lands"`
+	KeptLands        []LandRate     `json:"kept_lands"`
+}
+
+// HandSizeRate is how often the kept hand had a given size.
+type HandSizeRate struct {
+	HandSize int     `json:"hand_size"`
+	Rate     float64 `json:"rate"`
+}
+
+// LandRate is how often a hand held a given number of lands.
+type LandRate struct {
+	Lands int     `json:"lands"`
+	Rate  float64 `json:"rate"`
+}
+
+// Mulligan plays out opening hands under the London mulligan: each
+// mulligan draws a fresh seven and puts one more card on the bottom. The
+// cards bottomed are the ones the keep rule cares least about. OpeningLands
+// is the land count of every first seven; KeptLands that of the hands kept,
+// after bottoming.
+func Mulligan(library []models.Card, opts MulliganOptions) MulliganReport {
+	rng := newRand(opts.Seed)
+	report := MulliganReport{Seed: opts.Seed, Trials: opts.Trials, Library: len(library)}
+	if opts.Trials <= 0 || len(library) == 0 {
+		report.KeptAt, report.OpeningLands, report.KeptLands = []HandSizeRate{}, []LandRate{}, []LandRate{}
+		return report
+	}
+
+	mustHave := make(map[string]bool, len(opts.Keep.MustHave))
+	for _, name := range opts.Keep.MustHave {
+		mustHave[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	copies := describe(library, mustHave)
+
+	order := make([]int, len(library))
+	for i := range order {
+		order[i] = i
+	}
+	keptAt := make(map[int]int)
+	openingLands := make(map[int]int)
+	keptLands := make(map[int]int)
+	forced, mulligans := 0, 0
+	for trial := 0; trial < opts.Trials; trial++ {
+		for taken := 0; ; taken++ {
+			seven := drawIndexes(order, HandSize, rng)
+			if taken == 0 {
+				openingLands[countLands(copies, seven)]++
+			}
+
+			bottom := taken
+			if opts.FreeMulligan && taken > 0 {
+				bottom--
+			}
+			hand := bottomCards(copies, seven, bottom, opts.Keep)
+
+			keep := opts.Keep.accepts(copies, hand, len(mustHave))
+			if !keep && len(hand)-1 < opts.MinHandSize {
+				// Another mulligan would go below the smallest hand.
+				keep = true
+				forced++
+			}
+			if keep {
+				keptAt[len(hand)]++
+				keptLands[countLands(copies, hand)]++
+				mulligans += taken
+				break
+			}
+		}
+	}
+
+	trials := float64(opts.Trials)
+	report.ForcedKeepRate = round4(float64(forced) / trials)
+	report.AverageMulligans = round4(float64(mulligans) / trials)
+	for _, size := range sortedKeys(keptAt) {
+		report.KeptAt = append(report.KeptAt, HandSizeRate{HandSize: size, Rate: round4(float64(keptAt[size]) / trials)})
+	}
+	sort.Slice(report.KeptAt, func(i, j int) bool { return report.KeptAt[i].HandSize > report.KeptAt[j].HandSize })
+	report.OpeningLands = landRates(openingLands, trials)
+	report.KeptLands = landRates(keptLands, trials)
+	return report
+}
+
+// copyInfo holds what the keep rule needs to know about one card of the
+// library, worked out once rather than on every draw.
+type copyInfo struct {
+	land      bool
+	manaValue float64
+	// mustHave is the card's lower-cased name if the rule asks for it.
+	mustHave string
+}
+
+func describe(library []models.Card, mustHave map[string]bool) []copyInfo {
+	copies := make([]copyInfo, len(library))
+	for i, card := range library {
+		copies[i] = copyInfo{land: stats.IsLand(card), manaValue: manaValue(card)}
+		if name := strings.ToLower(card.Name); mustHave[name] {
+			copies[i].mustHave = name
+		}
+	}
+	return copies
+}
+
+func (r KeepRule) accepts(copies []copyInfo, hand []int, mustHave int) bool {
+	lands := countLands(copies, hand)
+	if lands < r.MinLands || lands > r.MaxLands {
+		return false
+	}
+	if mustHave == 0 {
+		return true
+	}
+	held := make(map[string]bool)
+	for _, i := range hand {
+		if name := copies[i].mustHave; name != "" {
+			held[name] = true
+		}
+	}
+	if r.MustHaveAll {
+		return len(held) == mustHave
+	}
+	return len(held) > 0
+}
+
+// bottomCards puts n cards of the hand on the bottom and returns the rest.
+// Lands beyond the rule's maximum go first, then nonland cards the rule
+// does not ask for, most expensive first, then the other lands.
+func bottomCards(copies []copyInfo, hand []int, n int, rule KeepRule) []int {
+	if n <= 0 {
+		return hand
+	}
+	n = min(n, len(hand))
+	excess := countLands(copies, hand) - rule.MaxLands
+
+	type candidate struct {
+		index int
+		rank  float64
+	}
+	candidates := make([]candidate, 0, len(hand))
+	for i, c := range hand {
+		info := copies[c]
+		var rank float64
+		switch {
+		case info.land && excess > 0:
+			rank = 0
+			excess--
+		case info.mustHave != "":
+			rank = 3
+		case !info.land:
+			// Cheaper spells rank later, so they stay in hand.
+			rank = 1 + 1/(1+info.manaValue)
+		default:
+			rank = 2
+		}
+		candidates = append(candidates, candidate{i, rank})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].rank < candidates[j].rank })
+
+	bottomed := make(map[int]bool, n)
+	for _, c := range candidates[:n] {
+		bottomed[c.index] = true
+	}
+	kept := make([]int, 0, len(hand)-n)
+	for i, c := range hand {
+		if !bottomed[i] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func countLands(copies []copyInfo, hand []int) int {
+	n := 0
+	for _, i := range hand {
+		if copies[i].land {
+			n++
+		}
+	}
+	return n
+}
+
+// manaValue reads a card's mana value from its cost, falling back to the
+// cached cmc for costs that cannot be read.
+func manaValue(card models.Card) float64 {
+	if cost, err := manacost.Parse(card.ManaCost); err == nil {
+		return cost.ManaValue()
+	}
+	return float64(card.CMC)
+}
+
+func landRates(counts map[int]int, trials float64) []LandRate {
+	rates := make([]LandRate, 0, len(counts))
+	for _, lands := range sortedKeys(counts) {
+		rates = append(rates, LandRate{Lands: lands, Rate: round4(float64(counts[lands]) / trials)})
+	}
+	return rates
+}
+
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func round4(f float64) float64 {
+	return math.Round(f*10000) / 10000
+}
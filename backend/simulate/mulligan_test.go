@@ -0,0 +1,38 @@
+package simulate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBottomCards(t *testing.T) {
+	land := copyInfo{land: true}
+	cheap := copyInfo{manaValue: 1}
+	pricey := copyInfo{manaValue: 6}
+	wanted := copyInfo{manaValue: 7, mustHave: "craterhoof behemoth"}
+	// The hand is indexes into copies.
+	copies := []copyInfo{land, land, land, land, land, cheap, pricey, wanted}
+
+	tests := []struct {
+		name string
+		hand []int
+		n    int
+		rule KeepRule
+		want []int
+	}{
+		{"nothing to bottom", []int{0, 1, 5, 6}, 0, KeepRule{MaxLands: 4}, []int{0, 1, 5, 6}},
+		{"excess lands first", []int{5, 0, 6, 1, 2, 3, 4}, 1, KeepRule{MaxLands: 4}, []int{5, 6, 1, 2, 3, 4}},
+		{"every excess land before spells", []int{0, 1, 2, 3, 4, 5, 6}, 2, KeepRule{MaxLands: 3}, []int{2, 3, 4, 5, 6}},
+		{"expensive spell when lands are within the rule", []int{0, 1, 2, 5, 6, 7}, 1, KeepRule{MaxLands: 4}, []int{0, 1, 2, 5, 7}},
+		{"spells before lands", []int{0, 1, 2, 5, 6, 7}, 2, KeepRule{MaxLands: 4}, []int{0, 1, 2, 7}},
+		{"must-have cards last", []int{0, 5, 7}, 2, KeepRule{MaxLands: 4}, []int{7}},
+		{"no more than the hand", []int{0, 5}, 3, KeepRule{MaxLands: 4}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bottomCards(copies, tt.hand, tt.n, tt.rule); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package stats computes the statistics shown with a deck: its mana curve,
+// card types, color requirements and the colors its lands produce. Server
+// and clients then agree on the numbers instead of each counting its own.
+package stats
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mana-tomb/backend/manacost"
+	"mana-tomb/backend/models"
+)
+
+// curveCap is the mana value from which cards share the curve's last bucket.
+const curveCap = 7
+
+// Colors lists the five colors in WUBRG order.
+var Colors = manacost.Colors
+
+// sourceOrder is the order of the colors a land can produce.
+var sourceOrder = []string{"W", "U", "B", "R", "G", "C"}
+
+// cardTypes are the card types counted in the type breakdown, in display
+// order. Tribal was renamed Kindred and is counted as such.
+var cardTypes = []string{"Creature", "Planeswalker", "Battle", "Instant", "Sorcery", "Artifact", "Enchantment", "Kindred", "Land"}
+
+var permanentTypes = map[string]bool{
+	"Creature": true, "Planeswalker": true, "Battle": true, "Artifact": true, "Enchantment": true, "Land": true,
+}
+
+var basicLandTypes = map[string]string{
+	"Plains":   "W",
+	"Island":   "U",
+	"Swamp":    "B",
+	"Mountain": "R",
+	"Forest":   "G",
+}
+
+var (
+	symbolPattern  = regexp.MustCompile(`\{([^}]+)\}`)
+	addManaPattern = regexp.MustCompile(`(?i)\badd\b[^.]*`)
+)
+
+// Compute returns the statistics of the given cards, each carrying the
+// quantity in the deck. The caller picks which boards to include.
+func Compute(cards []models.Card) models.DeckStats {
+	result := models.DeckStats{
+		ManaCurve:   make([]models.CurveBucket, curveCap+1),
+		Types:       make(map[string]int),
+		Pips:        make(map[string]int),
+		LandSources: make(map[string]int),
+		Devotion:    make(map[string]int),
+	}
+	for i := range result.ManaCurve {
+		result.ManaCurve[i].CMC = strconv.Itoa(i)
+	}
+	result.ManaCurve[curveCap].CMC = strconv.Itoa(curveCap) + "+"
+
+	var totalCMC float64
+	for _, card := range cards {
+		n := card.Quantity
+		result.CardCount += n
+
+		// The mana value comes from the cost itself; the cached cmc is only
+		// used for costs that cannot be read.
+		manaValue := float64(card.CMC)
+		cost, err := manacost.Parse(card.ManaCost)
+		if err == nil {
+			manaValue = cost.ManaValue()
+		}
+
+		bucket := &result.ManaCurve[min(int(math.Floor(manaValue)), curveCap)]
+		permanent := false
+		for _, t := range Types(card.TypeLine) {
+			result.Types[t] += n
+			permanent = permanent || permanentTypes[t]
+		}
+		if permanent {
+			for _, color := range Colors {
+				if d := cost.Devotion(color); d > 0 {
+					result.Devotion[color] += d * n
+				}
+			}
+		}
+
+		if IsLand(card) {
+			result.Lands += n
+			bucket.Lands += n
+			for _, color := range LandSources(card) {
+				result.LandSources[color] += n
+			}
+			continue
+		}
+
+		result.Nonlands += n
+		bucket.Nonlands += n
+		totalCMC += manaValue * float64(n)
+
+		for color, count := range cost.Pips() {
+			result.Pips[color] += count * n
+		}
+		result.HybridPips += cost.Hybrid() * n
+		result.PhyrexianPips += cost.Phyrexian() * n
+	}
+
+	if result.Nonlands > 0 {
+		result.AverageCMC = math.Round(totalCMC/float64(result.Nonlands)*100) / 100
+	}
+	return result
+}
+
+// frontFace returns the part of a double-faced or split card's text that
+// belongs to its front face.
+func frontFace(s string) string {
+	front, _, _ := strings.Cut(s, " // ")
+	return front
+}
+
+// IsLand reports whether the card's front face is a land. Modal double-faced
+// cards with a spell on the front count as spells.
+func IsLand(card models.Card) bool {
+	for _, t := range Types(card.TypeLine) {
+		if t == "Land" {
+			return true
+		}
+	}
+	return false
+}
+
+// Types returns the card types on the front face of a type line, such as
+// Artifact and Creature for "Legendary Artifact Creature — Golem".
+func Types(typeLine string) []string {
+	main, _, _ := strings.Cut(frontFace(typeLine), "—")
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(main) {
+		if w == "Tribal" {
+			w = "Kindred"
+		}
+		words[w] = true
+	}
+
+	var types []string
+	for _, t := range cardTypes {
+		if words[t] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// LandSources returns the colors a land can produce, read from its basic
+// land types and the mana abilities in its rules text. "C" stands for
+// colorless mana.
+func LandSources(card models.Card) []string {
+	produces := make(map[string]bool)
+
+	_, subtypes, _ := strings.Cut(frontFace(card.TypeLine), "—")
+	for _, w := range strings.Fields(subtypes) {
+		if color, ok := basicLandTypes[w]; ok {
+			produces[color] = true
+		}
+	}
+
+	for _, ability := range addManaPattern.FindAllString(frontFace(card.OracleText), -1) {
+		lower := strings.ToLower(ability)
+		if strings.Contains(lower, "any color") || strings.Contains(lower, "any one color") ||
+			strings.Contains(lower, "any combination of colors") || strings.Contains(lower, "any type") {
+			for _, color := range Colors {
+				produces[color] = true
+			}
+		}
+		for _, m := range symbolPattern.FindAllStringSubmatch(ability, -1) {
+			symbol, err := manacost.ParseSymbol(m[1])
+			if err != nil {
+				continue
+			}
+			for _, color := range symbol.Colors {
+				produces[color] = true
+			}
+			if symbol.Colorless {
+				produces["C"] = true
+			}
+		}
+	}
+
+	var colors []string
+	for _, color := range sourceOrder {
+		if produces[color] {
+			colors = append(colors, color)
+		}
+	}
+	return colors
+}
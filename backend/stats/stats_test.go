@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"testing"
+
+	"mana-tomb/backend/models"
+)
+
+func TestComputeManaValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		card   models.Card
+		bucket int
+	}{
+		{"read from the cost", models.Card{ManaCost: "{2/W}{2/W}", CMC: 2}, 4},
+		{"cached cmc when the cost cannot be read", models.Card{ManaCost: "{Q}{Q}", CMC: 5}, 5},
+		{"no cost", models.Card{CMC: 0}, 0},
+		{"capped at the last bucket", models.Card{ManaCost: "{15}", CMC: 15}, curveCap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.card.TypeLine = "Sorcery"
+			tt.card.Quantity = 1
+			result := Compute([]models.Card{tt.card})
+			for i, bucket := range result.ManaCurve {
+				want := 0
+				if i == tt.bucket {
+					want = 1
+				}
+				if bucket.Nonlands != want {
+					t.Errorf("bucket %s: got %d nonlands, want %d", bucket.CMC, bucket.Nonlands, want)
+				}
+			}
+		})
+	}
+}
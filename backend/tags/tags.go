@@ -0,0 +1,240 @@
+// Package tags classifies cards by what they do in a deck: ramp, card
+// draw, removal and so on. The rules read the oracle text and type line
+// cached for every card, so a deck is tagged without anyone doing it by
+// hand. Owners can still override the result per deck entry.
+package tags
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/models"
+)
+
+// Functional tags, as stored in deck_cards.tag_overrides.
+const (
+	Ramp         = "ramp"
+	Draw         = "draw"
+	Removal      = "removal"
+	Wipe         = "wipe"
+	Tutor        = "tutor"
+	Counterspell = "counterspell"
+	Protection   = "protection"
+)
+
+// Tag describes a functional tag.
+type Tag struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+}
+
+// functional are listed in the order tags are shown.
+var functional = []Tag{
+	{Name: Ramp, Label: "Ramp"},
+	{Name: Draw, Label: "Card draw"},
+	{Name: Removal, Label: "Targeted removal"},
+	{Name: Wipe, Label: "Board wipe"},
+	{Name: Tutor, Label: "Tutor"},
+	{Name: Counterspell, Label: "Counterspell"},
+	{Name: Protection, Label: "Protection"},
+}
+
+// All returns the functional tags in display order.
+func All() []Tag {
+	return append([]Tag(nil), functional...)
+}
+
+// Known reports whether name is a functional tag.
+func Known(name string) bool {
+	for _, t := range functional {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var reminderPattern = regexp.MustCompile(`\([^()]*\)`)
+
+// rules map each tag to the patterns of lower-cased rules text that earn
+// it. A card gets the tag when any pattern matches.
+var rules = map[string][]*regexp.Regexp{
+	Ramp: {
+		regexp.MustCompile(`\{t\}[^:.]*: add `),
+		regexp.MustCompile(`search your library for [^.]*(land|plains|island|swamp|mountain|forest)[^.]* onto the battlefield`),
+		regexp.MustCompile(`put (a|up to \w+) land cards? from your hand onto the battlefield`),
+		regexp.MustCompile(`play (an|two) additional lands?`),
+	},
+	Draw: {
+		regexp.MustCompile(`(^|[.:,\n]\s*|\bthen |\byou |\bmay )draws? (a|an|one|two|three|four|five|six|seven|x|that many) (additional )?cards?`),
+		regexp.MustCompile(`(^|[.:,\n]\s*|\byou |\bmay )draws? cards equal to`),
+		regexp.MustCompile(`draw a card for each`),
+	},
+	Removal: {
+		regexp.MustCompile(`(destroy|exile) (up to \w+ )?target (\w+ )*(creature|artifact|enchantment|planeswalker|permanent|battle)`),
+		regexp.MustCompile(`deals? (\d+|x|damage equal to [^.]*?) (damage )?to (any target|target creature|target planeswalker|target attacking|target blocking|target battle)`),
+		regexp.MustCompile(`target creature gets -\d+/-\d+`),
+		regexp.MustCompile(`target (player|opponent) sacrifices (a|an) (creature|artifact|enchantment|planeswalker|permanent|nonland permanent)`),
+		regexp.MustCompile(`return target (nonland )?(creature|permanent|artifact|enchantment|planeswalker)[^.]* to its owner's hand`),
+	},
+	Wipe: {
+		regexp.MustCompile(`(destroy|exile) (all|each) (other )?(\w+ )*(creatures|permanents|artifacts|enchantments|planeswalkers)`),
+		regexp.MustCompile(`all (other )?creatures get -\d+/-\d+`),
+		regexp.MustCompile(`deals? (\d+|x) damage to each (other )?creature`),
+		regexp.MustCompile(`return all (other )?(nonland )?(creatures|permanents)[^.]* to their owners' hands`),
+		regexp.MustCompile(`each (player|opponent) sacrifices (all|each|\w+) (creatures?|nonland permanents?|permanents?)`),
+	},
+	Tutor: {
+		regexp.MustCompile(`search your library for (a|an|up to \w+|\w+) [^.]*cards?`),
+	},
+	Counterspell: {
+		regexp.MustCompile(`counter target (\w+ )*(spell|ability)`),
+		regexp.MustCompile(`counter (it|that spell)`),
+	},
+	Protection: {
+		regexp.MustCompile(`(gains?|have|has|get) [^.]*(hexproof|indestructible|shroud|protection from)`),
+		regexp.MustCompile(`phases? out`),
+		regexp.MustCompile(`(spells|permanents) you control can't be countered`),
+	},
+}
+
+// Classify returns the functional tags of a card in display order.
+func Classify(card models.Card) []string {
+	text := strings.ToLower(reminderPattern.ReplaceAllString(card.OracleText, ""))
+	front, _, _ := strings.Cut(card.TypeLine, " // ")
+	land := strings.Contains(front, "Land")
+
+	found := make(map[string]bool)
+	for tag, patterns := range rules {
+		for _, p := range patterns {
+			if p.MatchString(text) {
+				found[tag] = true
+				break
+			}
+		}
+	}
+
+	// A land that taps for mana or fetches another land is just a land.
+	if land {
+		delete(found, Ramp)
+	}
+	// Searching for a land is ramp, not a tutor.
+	if found[Tutor] && !searchesForNonland(text) {
+		delete(found, Tutor)
+	}
+	return inOrder(found)
+}
+
+// searchesForNonland reports whether any library search in the text can
+// find something other than a land.
+func searchesForNonland(text string) bool {
+	for _, sentence := range strings.Split(text, ".") {
+		_, search, ok := strings.Cut(sentence, "search your library for ")
+		if !ok {
+			continue
+		}
+		target, _, _ := strings.Cut(search, ",")
+		if !strings.Contains(target, "land") && !strings.Contains(target, "plains") && !strings.Contains(target, "island") &&
+			!strings.Contains(target, "swamp") && !strings.Contains(target, "mountain") && !strings.Contains(target, "forest") {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns a card's tags once a deck entry's overrides are applied:
+// true adds a tag the rules missed, false removes one they got wrong.
+func Apply(auto []string, overrides map[string]bool) []string {
+	found := make(map[string]bool, len(auto))
+	for _, tag := range auto {
+		found[tag] = true
+	}
+	for tag, on := range overrides {
+		if on {
+			found[tag] = true
+		} else {
+			delete(found, tag)
+		}
+	}
+	return inOrder(found)
+}
+
+func inOrder(found map[string]bool) []string {
+	result := make([]string, 0, len(found))
+	for _, t := range functional {
+		if found[t.Name] {
+			result = append(result, t.Name)
+			delete(found, t.Name)
+		}
+	}
+	// Anything else comes after, alphabetically.
+	var rest []string
+	for tag := range found {
+		rest = append(rest, tag)
+	}
+	sort.Strings(rest)
+	return append(result, rest...)
+}
+
+// Count adds up the copies carrying each tag. The cards carry their
+// quantity and their tags, overrides applied.
+func Count(cards []models.Card) map[string]int {
+	counts := make(map[string]int)
+	for _, card := range cards {
+		for _, tag := range card.Tags {
+			counts[tag] += card.Quantity
+		}
+	}
+	return counts
+}
+
+// Limits on the free-form tags owners put on deck entries. MaxCustomLength
+// matches the size of the deck_cards.custom_tags elements.
+const (
+	MaxCustomLength  = 50
+	MaxCustomPerCard = 20
+)
+
+var (
+	ErrInvalidCustom = errors.New("tags must be between 1 and 50 characters")
+	ErrTooManyCustom = errors.New("a card can have at most 20 tags")
+)
+
+// CleanCustom trims free-form tags and drops repeats, comparing without
+// regard to case. The first spelling of a tag is kept.
+func CleanCustom(custom []string) ([]string, error) {
+	seen := make(map[string]bool, len(custom))
+	cleaned := make([]string, 0, len(custom))
+	for _, tag := range custom {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" || len([]rune(tag)) > MaxCustomLength {
+			return nil, ErrInvalidCustom
+		}
+		if key := strings.ToLower(tag); !seen[key] {
+			seen[key] = true
+			cleaned = append(cleaned, tag)
+		}
+	}
+	if len(cleaned) > MaxCustomPerCard {
+		return nil, ErrTooManyCustom
+	}
+	return cleaned, nil
+}
+
+// Has reports whether a card carries a tag, functional or free-form. Case
+// is ignored.
+func Has(card models.Card, tag string) bool {
+	for _, t := range card.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	for _, t := range card.CustomTags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package validation
+
+// commanderBanned is the Commander banned list, keyed by lower-case card
+// name. It follows the official list as of the September 2024 update; keep
+// it in step with https://mtgcommander.net/index.php/banned-list/.
+var commanderBanned = map[string]bool{
+	"ancestral recall":            true,
+	"balance":                     true,
+	"biorhythm":                   true,
+	"black lotus":                 true,
+	"braids, cabal minion":        true,
+	"channel":                     true,
+	"chaos orb":                   true,
+	"coalition victory":           true,
+	"dockside extortionist":       true,
+	"emrakul, the aeons torn":     true,
+	"erayo, soratami ascendant":   true,
+	"falling star":                true,
+	"fastbond":                    true,
+	"flash":                       true,
+	"gifts ungiven":               true,
+	"griselbrand":                 true,
+	"hullbreacher":                true,
+	"iona, shield of emeria":      true,
+	"jeweled lotus":               true,
+	"karakas":                     true,
+	"leovold, emissary of trest":  true,
+	"library of alexandria":       true,
+	"limited resources":           true,
+	"lutri, the spellchaser":      true,
+	"mana crypt":                  true,
+	"mox emerald":                 true,
+	"mox jet":                     true,
+	"mox pearl":                   true,
+	"mox ruby":                    true,
+	"mox sapphire":                true,
+	"nadu, winged wisdom":         true,
+	"panoptic mirror":             true,
+	"paradox engine":              true,
+	"primeval titan":              true,
+	"prophet of kruphix":          true,
+	"recurring nightmare":         true,
+	"rofellos, llanowar emissary": true,
+	"shahrazad":                   true,
+	"sundering titan":             true,
+	"sway of the stars":           true,
+	"sylvan primordial":           true,
+	"time vault":                  true,
+	"time walk":                   true,
+	"tinker":                      true,
+	"tolarian academy":            true,
+	"trade secrets":               true,
+	"upheaval":                    true,
+	"yawgmoth's bargain":          true,
+	// Ante cards.
+	"amulet of quoth":     true,
+	"bronze tablet":       true,
+	"contract from below": true,
+	"darkpact":            true,
+	"demonic attorney":    true,
+	"jeweled bird":        true,
+	"rebirth":             true,
+	"tempest efreet":      true,
+	"timmerian fiends":    true,
+	// Cards removed from all formats for racist imagery or text.
+	"cleanse":               true,
+	"crusade":               true,
+	"imprison":              true,
+	"invoke prejudice":      true,
+	"jihad":                 true,
+	"pradesh gypsies":       true,
+	"stone-throwing devils": true,
+}
@@ -0,0 +1,28 @@
+package validation
+
+import "fmt"
+
+// checkBudget warns when the deck costs more than its budget, or might,
+// because some of its cards have no price.
+func checkBudget(deck Deck) []Violation {
+	if deck.Budget == nil {
+		return nil
+	}
+	budget := *deck.Budget
+
+	if deck.Value > budget {
+		message := fmt.Sprintf("The deck costs $%.2f, $%.2f over its budget of $%.2f", deck.Value, deck.Value-budget, budget)
+		if len(deck.Unpriced) > 0 {
+			message += fmt.Sprintf(", not counting %d unpriced cards", len(deck.Unpriced))
+		}
+		return []Violation{{Rule: "budget", Message: message}}
+	}
+	if len(deck.Unpriced) > 0 {
+		return []Violation{{
+			Rule:    "budget_unpriced",
+			Message: fmt.Sprintf("The deck costs $%.2f of its $%.2f budget, but some cards have no price", deck.Value, budget),
+			Cards:   deck.Unpriced,
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mana-tomb/backend/models"
+)
+
+// commanderDeckSize is the exact size of a Commander deck, commanders included.
+const commanderDeckSize = 100
+
+const anyNumberText = "a deck can have any number of cards named"
+
+var (
+	upToText    = regexp.MustCompile(`(?i)a deck can have up to (\w+) cards named`)
+	numberWords = map[string]int{
+		"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	}
+)
+
+// validateCommander applies the Commander deckbuilding rules: a legal
+// commander or commander pair, exactly 100 cards, singleton, color identity
+// and the banned list. A companion sits outside the 100 cards.
+func validateCommander(deck Deck) []Violation {
+	var violations []Violation
+
+	if len(deck.Commanders) == 0 {
+		violations = append(violations, Violation{
+			Rule:    "missing_commander",
+			Message: "The deck has no commander",
+		})
+	} else if err := CheckCommanders(deck.Commanders); err != nil {
+		violations = append(violations, Violation{
+			Rule:    "invalid_commander",
+			Message: capitalize(err.Error()),
+			Cards:   names(deck.Commanders),
+		})
+	}
+
+	if deck.Companion != nil {
+		if err := CheckCompanion(*deck.Companion); err != nil {
+			violations = append(violations, Violation{
+				Rule:    "invalid_companion",
+				Message: capitalize(err.Error()),
+				Cards:   []string{deck.Companion.Name},
+			})
+		}
+	}
+
+	total := 0
+	for _, card := range deck.Commanders {
+		total += card.Quantity
+	}
+	for _, card := range deck.Mainboard {
+		total += card.Quantity
+	}
+	if total != commanderDeckSize {
+		violations = append(violations, Violation{
+			Rule:    "deck_size",
+			Message: fmt.Sprintf("A Commander deck must have exactly %d cards including commanders, this one has %d", commanderDeckSize, total),
+		})
+	}
+
+	if v, ok := checkSingleton(deck); ok {
+		violations = append(violations, v)
+	}
+	if v, ok := checkColorIdentity(deck); ok {
+		violations = append(violations, v)
+	}
+	if v, ok := checkBanned(deck); ok {
+		violations = append(violations, v)
+	}
+
+	return violations
+}
+
+func canBeCommander(card models.Card) bool {
+	typeLine := strings.ToLower(card.TypeLine)
+	if strings.Contains(typeLine, "legendary") && strings.Contains(typeLine, "creature") {
+		return true
+	}
+	return strings.Contains(strings.ToLower(card.OracleText), "can be your commander")
+}
+
+// copyLimit returns how many copies of a card a deck may hold, or -1 for
+// no limit. Basic lands and "any number of cards named" cards are unlimited.
+func copyLimit(card models.Card) int {
+	if isBasicLand(card) {
+		return -1
+	}
+	text := strings.ToLower(card.OracleText)
+	if strings.Contains(text, anyNumberText) {
+		return -1
+	}
+	if m := upToText.FindStringSubmatch(text); m != nil {
+		if n, ok := numberWords[strings.ToLower(m[1])]; ok {
+			return n
+		}
+	}
+	return 1
+}
+
+func isBasicLand(card models.Card) bool {
+	typeLine := strings.ToLower(card.TypeLine)
+	return strings.Contains(typeLine, "basic") && strings.Contains(typeLine, "land")
+}
+
+func checkSingleton(deck Deck) (Violation, bool) {
+	// Different printings of a card share a name and count together.
+	counts := make(map[string]int)
+	limits := make(map[string]int)
+	for _, card := range append(append([]models.Card{}, deck.Commanders...), deck.Mainboard...) {
+		counts[card.Name] += card.Quantity
+		limits[card.Name] = copyLimit(card)
+	}
+
+	var offenders []string
+	for name, count := range counts {
+		if limit := limits[name]; limit >= 0 && count > limit {
+			offenders = append(offenders, name)
+		}
+	}
+	if len(offenders) == 0 {
+		return Violation{}, false
+	}
+	sort.Strings(offenders)
+	return Violation{
+		Rule:    "singleton",
+		Message: "Apart from basic lands, a Commander deck may contain only one copy of each card",
+		Cards:   offenders,
+	}, true
+}
+
+func checkColorIdentity(deck Deck) (Violation, bool) {
+	if len(deck.Commanders) == 0 {
+		// Without a commander there is no identity to check against.
+		return Violation{}, false
+	}
+
+	allowed := make(map[string]bool)
+	for _, commander := range deck.Commanders {
+		for _, color := range commander.Identity() {
+			allowed[color] = true
+		}
+	}
+
+	// The companion starts outside the game but must fit the identity too.
+	cards := deck.Mainboard
+	if deck.Companion != nil {
+		cards = append(append([]models.Card{}, cards...), *deck.Companion)
+	}
+
+	var offenders []string
+	for _, card := range cards {
+		for _, color := range card.Identity() {
+			if !allowed[color] {
+				offenders = append(offenders, card.Name)
+				break
+			}
+		}
+	}
+	if len(offenders) == 0 {
+		return Violation{}, false
+	}
+	return Violation{
+		Rule:    "color_identity",
+		Message: "Every card must be within the commander's color identity (" + identityString(allowed) + ")",
+		Cards:   offenders,
+	}, true
+}
+
+func checkBanned(deck Deck) (Violation, bool) {
+	cards := append(append([]models.Card{}, deck.Commanders...), deck.Mainboard...)
+	if deck.Companion != nil {
+		cards = append(cards, *deck.Companion)
+	}
+
+	var offenders []string
+	for _, card := range cards {
+		if commanderBanned[strings.ToLower(card.Name)] {
+			offenders = append(offenders, card.Name)
+		}
+	}
+	if len(offenders) == 0 {
+		return Violation{}, false
+	}
+	return Violation{
+		Rule:    "banned",
+		Message: "These cards are banned in Commander",
+		Cards:   offenders,
+	}, true
+}
+
+func identityString(colors map[string]bool) string {
+	var b strings.Builder
+	for _, c := range []string{"W", "U", "B", "R", "G"} {
+		if colors[c] {
+			b.WriteString(c)
+		}
+	}
+	if b.Len() == 0 {
+		return "colorless"
+	}
+	return b.String()
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func names(cards []models.Card) []string {
+	out := make([]string, 0, len(cards))
+	for _, card := range cards {
+		out = append(out, card.Name)
+	}
+	return out
+}
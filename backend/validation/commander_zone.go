@@ -0,0 +1,115 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mana-tomb/backend/models"
+)
+
+var partnerWith = regexp.MustCompile(`(?im)^partner with ([^(\n]+)`)
+
+// CheckCommanders reports whether the cards can share the commander zone:
+// a single commander, or a pair joined by Partner, "Partner with", Friends
+// forever, "Choose a Background" or Doctor's companion.
+func CheckCommanders(commanders []models.Card) error {
+	switch len(commanders) {
+	case 0:
+		return errors.New("a deck needs a commander")
+	case 1:
+		if !canBeCommander(commanders[0]) {
+			return fmt.Errorf("%s cannot be a commander", commanders[0].Name)
+		}
+		return nil
+	case 2:
+		return checkPair(commanders[0], commanders[1])
+	default:
+		return errors.New("a deck can have at most two commanders")
+	}
+}
+
+func checkPair(a, b models.Card) error {
+	if a.Name == b.Name {
+		return fmt.Errorf("%s cannot partner with itself", a.Name)
+	}
+
+	// A Background only works next to a commander that chooses one.
+	if isBackground(a) || isBackground(b) {
+		background, other := a, b
+		if isBackground(b) {
+			background, other = b, a
+		}
+		if isBackground(other) {
+			return errors.New("two Backgrounds cannot be commanders together")
+		}
+		if hasKeywordLine(other, "choose a background") && canBeCommander(other) {
+			return nil
+		}
+		return fmt.Errorf("%s can only be a commander alongside a commander with \"Choose a Background\"", background.Name)
+	}
+
+	for _, card := range []models.Card{a, b} {
+		if !canBeCommander(card) {
+			return fmt.Errorf("%s cannot be a commander", card.Name)
+		}
+	}
+
+	switch {
+	case hasPartner(a) && hasPartner(b):
+		return nil
+	case partnersWith(a, b.Name) && partnersWith(b, a.Name):
+		return nil
+	case hasKeywordLine(a, "friends forever") && hasKeywordLine(b, "friends forever"):
+		return nil
+	case isDoctorPair(a, b) || isDoctorPair(b, a):
+		return nil
+	}
+	return fmt.Errorf("%s and %s cannot be commanders together", a.Name, b.Name)
+}
+
+// CheckCompanion reports whether a card can be a deck's companion.
+func CheckCompanion(card models.Card) error {
+	if !strings.Contains(strings.ToLower(card.OracleText), "companion —") {
+		return fmt.Errorf("%s does not have companion", card.Name)
+	}
+	return nil
+}
+
+func isBackground(card models.Card) bool {
+	return strings.Contains(strings.ToLower(card.TypeLine), "background")
+}
+
+// hasPartner matches the plain Partner keyword, not "Partner with".
+func hasPartner(card models.Card) bool {
+	return hasKeywordLine(card, "partner")
+}
+
+func partnersWith(card models.Card, name string) bool {
+	for _, m := range partnerWith.FindAllStringSubmatch(card.OracleText, -1) {
+		if strings.EqualFold(strings.TrimSpace(m[1]), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDoctorPair(companion, doctor models.Card) bool {
+	return hasKeywordLine(companion, "doctor's companion") &&
+		strings.Contains(strings.ToLower(doctor.TypeLine), "time lord doctor")
+}
+
+// hasKeywordLine looks for a keyword ability on its own line or in a
+// comma-separated keyword line, ignoring any reminder text after it.
+func hasKeywordLine(card models.Card, keyword string) bool {
+	for _, line := range strings.Split(strings.ToLower(card.OracleText), "\n") {
+		line, _, _ = strings.Cut(line, "(")
+		for _, part := range strings.Split(line, ",") {
+			if strings.TrimSpace(part) == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Package validation checks decks against the deckbuilding rules of their format.
+package validation
+
+import (
+	"strings"
+
+	"mana-tomb/backend/models"
+)
+
+// Deck is the part of a deck the validators look at. Cards on boards that
+// do not count towards the deck, such as the maybeboard, are left out.
+//
+// Budget is the deck's price ceiling in US dollars, or nil for none. Value
+// is what its cards cost and Unpriced names the cards without a price; they
+// are only needed when Budget is set.
+type Deck struct {
+	Format     string
+	Commanders []models.Card
+	Companion  *models.Card
+	Mainboard  []models.Card
+	Budget     *float64
+	Value      float64
+	Unpriced   []string
+}
+
+// Violation is one broken rule. Cards names the offending cards, if any.
+type Violation struct {
+	Rule    string   `json:"rule"`
+	Message string   `json:"message"`
+	Cards   []string `json:"cards,omitempty"`
+}
+
+// Result is the outcome of validating a deck. Warnings point out problems
+// that do not make the deck illegal, such as going over its budget, and
+// apply to every format. RulesChecked is false for formats without a
+// validator; Legal is nil then.
+type Result struct {
+	Format       string      `json:"format"`
+	RulesChecked bool        `json:"rules_checked"`
+	Legal        *bool       `json:"legal"`
+	Violations   []Violation `json:"violations"`
+	Warnings     []Violation `json:"warnings"`
+}
+
+var validators = map[string]func(Deck) []Violation{
+	"commander": validateCommander,
+}
+
+// Validate checks the deck against the rules of its format, when there is
+// a validator for it, and against its budget.
+func Validate(deck Deck) Result {
+	format := strings.ToLower(strings.TrimSpace(deck.Format))
+	if format == "" {
+		// Decks created without a format fall back to the column default.
+		format = "commander"
+	}
+
+	result := Result{Format: format, Violations: []Violation{}, Warnings: []Violation{}}
+	if validate, ok := validators[format]; ok {
+		if violations := validate(deck); violations != nil {
+			result.Violations = violations
+		}
+		legal := len(result.Violations) == 0
+		result.RulesChecked, result.Legal = true, &legal
+	}
+	if warnings := checkBudget(deck); warnings != nil {
+		result.Warnings = warnings
+	}
+	return result
+}
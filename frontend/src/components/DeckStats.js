@@ -1,5 +1,6 @@
-import React, { useMemo } from 'react';
+import React, { useState, useEffect } from 'react';
 import { BarChart, Bar, XAxis, YAxis, Tooltip, ResponsiveContainer, PieChart, Pie, Cell, Legend } from 'recharts';
+import { getDeckStats } from '../services/api';
 import './DeckStats.css';
 
 const COLORS = {
@@ -8,51 +9,32 @@ const COLORS = {
   'B': '#a69f9d',
   'R': '#eb9f82',
   'G': '#c4d3ca',
-  'Colorless': '#DCDCDC'
+  'C': '#DCDCDC'
 };
 
-function DeckStats({ cards }) {
-  // useMemo will only re-calculate the stats when the 'cards' prop changes.
-  const { manaCurve, colorDistribution } = useMemo(() => {
-    const curve = Array(8).fill(0).map((_, i) => ({ cmc: i, count: 0 })); // Bins for CMC 0 through 7+
-    const colors = { 'W': 0, 'U': 0, 'B': 0, 'R': 0, 'G': 0, 'Colorless': 0 };
+// The stats are computed by the server; 'cards' is only used to refetch
+// them whenever the deck's cards change.
+function DeckStats({ deckId, cards }) {
+  const [stats, setStats] = useState(null);
 
-    cards.forEach(card => {
-      // Mana Curve Calculation
-      const cmc = Math.floor(card.cmc);
-      if (cmc >= 7) {
-        curve[7].count += card.quantity; // Group all 7+ CMC cards together
-      } else if (cmc >= 0) {
-        curve[cmc].count += card.quantity;
-      }
+  useEffect(() => {
+    getDeckStats(deckId)
+      .then(response => setStats(response.data))
+      .catch(() => setStats(null));
+  }, [deckId, cards]);
 
-      // Color Distribution Calculation
-      if (card.colors && card.colors.length > 0) {
-        card.colors.forEach(color => {
-          if (colors.hasOwnProperty(color)) {
-            colors[color] += card.quantity;
-          }
-        });
-      } else {
-        colors['Colorless'] += card.quantity;
-      }
-    });
-    
-    // Format for Pie Chart, filtering out empty color slices
-    const pieData = Object.entries(colors)
-      .filter(([_, value]) => value > 0)
-      .map(([name, value]) => ({ name, value }));
+  if (!stats) return null;
 
-    // Add '+' to the last label of the mana curve
-    curve[7].cmc = '7+';
-
-    return { manaCurve: curve, colorDistribution: pieData };
-  }, [cards]);
+  const manaCurve = stats.mana_curve;
+  // Format for Pie Chart, filtering out empty color slices
+  const colorDistribution = Object.keys(COLORS)
+    .filter(color => stats.pips[color] > 0)
+    .map(color => ({ name: color, value: stats.pips[color] }));
 
   return (
     <div className="deck-stats-container">
       <div className="stat-chart">
-        <h3>Mana Curve</h3>
+        <h3>Mana Curve (average {stats.average_cmc})</h3>
         <ResponsiveContainer width="100%" height={250}>
           <BarChart data={manaCurve} margin={{ top: 5, right: 20, left: -10, bottom: 5 }}>
             <XAxis dataKey="cmc" stroke="#c0c0c0" />
@@ -61,12 +43,13 @@ function DeckStats({ cards }) {
               contentStyle={{ backgroundColor: '#242424', border: '1px solid #444' }}
               cursor={{ fill: 'rgba(106, 13, 173, 0.2)' }}
             />
-            <Bar dataKey="count" fill="#6a0dad" />
+            <Bar dataKey="nonlands" name="Nonlands" stackId="curve" fill="#6a0dad" />
+            <Bar dataKey="lands" name="Lands" stackId="curve" fill="#444" />
           </BarChart>
         </ResponsiveContainer>
       </div>
       <div className="stat-chart">
-        <h3>Color Distribution</h3>
+        <h3>Color Pips</h3>
         <ResponsiveContainer width="100%" height={250}>
           <PieChart>
             <Pie
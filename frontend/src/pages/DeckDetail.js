@@ -1,10 +1,10 @@
 import React, { useState, useEffect, useCallback } from 'react';
-import { useParams } from 'react-router-dom';
-import { getDeck, searchScryfall, addCardToDeck, removeCardFromDeck } from '../services/api';
+import { useParams, useNavigate, Link } from 'react-router-dom';
+import { getDeck, searchCards, addCardToDeck, removeCardFromDeck, forkDeck, parseImageUris } from '../services/api';
 import DeckStats from '../components/DeckStats'; // Import the new component
 import './DeckDetail.css';
 
-const CardList = ({ title, cards, onRemove }) => (
+const CardList = ({ title, board, cards, onRemove }) => (
   <div className="card-list-section">
     <h2>{title} ({cards.reduce((sum, card) => sum + card.quantity, 0)})</h2>
     {cards.length > 0 ? (
@@ -12,12 +12,12 @@ const CardList = ({ title, cards, onRemove }) => (
         {cards.map((card) => (
           <div key={card.id} className="card-grid-item">
             <img
-              src={JSON.parse(card.image_uris)?.normal || ''}
+              src={parseImageUris(card.image_uris)?.normal || ''}
               alt={card.name}
               loading="lazy"
             />
             <div className="card-actions-overlay">
-                <button onClick={() => onRemove(card.id)} className="remove-btn">Remove</button>
+                <button onClick={() => onRemove(card.id, board)} className="remove-btn">Remove</button>
             </div>
             <span className="quantity-badge">{card.quantity}x</span>
           </div>
@@ -41,7 +41,7 @@ const DeckSearch = ({ onAddCard }) => {
         setLoading(true);
         setError('');
         try {
-            const response = await searchScryfall(query);
+            const response = await searchCards(query);
             setResults(response.data.data || []);
         } catch (err) {
             setError('No cards found.');
@@ -59,7 +59,7 @@ const DeckSearch = ({ onAddCard }) => {
                     type="text"
                     value={query}
                     onChange={(e) => setQuery(e.target.value)}
-                    placeholder="Search Scryfall..."
+                    placeholder="Search cards..."
                 />
                 <button type="submit" disabled={loading}>{loading ? '...' : 'Search'}</button>
             </form>
@@ -84,6 +84,7 @@ function DeckDetail() {
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState('');
   const { deckId } = useParams();
+  const navigate = useNavigate();
 
   const fetchDeck = useCallback(async () => {
     try {
@@ -101,47 +102,60 @@ function DeckDetail() {
   }, [fetchDeck]);
 
   const handleAddCard = async (card, board) => {
-    const cardData = {
-      id: card.id, name: card.name, image_uris: JSON.stringify(card.image_uris), mana_cost: card.mana_cost,
-      cmc: card.cmc, type_line: card.type_line, oracle_text: card.oracle_text, colors: card.colors,
-      color_identity: card.color_identity,
-    };
     try {
-      await addCardToDeck(deckId, cardData, board);
+      await addCardToDeck(deckId, card.id, board);
       fetchDeck();
     } catch (err) {
       alert('Failed to add card.');
     }
   };
 
-  const handleRemoveCard = async (cardId) => {
+  const handleRemoveCard = async (cardId, board) => {
     try {
-        await removeCardFromDeck(deckId, cardId);
+        await removeCardFromDeck(deckId, cardId, board);
         fetchDeck();
     } catch (err) {
         alert('Failed to remove card.');
     }
   };
 
+  const handleFork = async () => {
+    try {
+      const response = await forkDeck(deckId);
+      navigate(`/decks/${response.data.id}`);
+    } catch (err) {
+      alert('Failed to fork deck.');
+    }
+  };
+
   if (loading) return <div>Loading deck...</div>;
   if (error) return <p className="error-message">{error}</p>;
   if (!deck) return <p>Deck not found.</p>;
 
+  const boards = deck.boards || [];
+  const mainboard = boards.find((board) => board.name === 'main')?.cards || [];
+
   return (
     <div className="deck-detail-container">
       <div className="deck-header">
         <h1>{deck.name}</h1>
         <p>Format: {deck.format}</p>
+        {deck.forked_from && <p>Forked from <Link to={`/decks/${deck.forked_from}`}>another deck</Link></p>}
+        <p>Forks: {deck.fork_count || 0}</p>
+        <button onClick={handleFork}>Fork</button>
       </div>
 
       {/* Render the stats component if there are cards in the mainboard */}
-      {deck.mainboard && deck.mainboard.length > 0 && <DeckStats cards={deck.mainboard} />}
+      {mainboard.length > 0 && <DeckStats deckId={deckId} cards={mainboard} />}
 
       <div className="deck-layout">
         <div className="deck-boards">
-            <CardList title="Main Deck" cards={deck.mainboard || []} onRemove={handleRemoveCard} />
-            <hr />
-            <CardList title="Maybeboard" cards={deck.maybeboard || []} onRemove={handleRemoveCard} />
+            {boards.map((board, index) => (
+              <React.Fragment key={board.name}>
+                {index > 0 && <hr />}
+                <CardList title={board.label} board={board.name} cards={board.cards || []} onRemove={handleRemoveCard} />
+              </React.Fragment>
+            ))}
         </div>
         <div className="deck-sidebar">
             <DeckSearch onAddCard={handleAddCard} />
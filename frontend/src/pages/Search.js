@@ -1,5 +1,5 @@
 import React, { useState, useEffect } from 'react';
-import { searchScryfall, getDecks, addCardToDeck } from '../services/api';
+import { searchCards, getDecks, addCardToDeck } from '../services/api';
 import './Search.css';
 
 function Search() {
@@ -35,7 +35,7 @@ function Search() {
     setResults([]);
     setAddCardMessage('');
     try {
-      const response = await searchScryfall(query);
+      const response = await searchCards(query);
       if (response.data && response.data.data) {
         setResults(response.data.data);
       } else {
@@ -55,21 +55,8 @@ function Search() {
     }
     setAddCardMessage(`Adding ${card.name}...`);
 
-    // We need to structure the card data to match our backend model
-    const cardData = {
-      id: card.id,
-      name: card.name,
-      image_uris: JSON.stringify(card.image_uris),
-      mana_cost: card.mana_cost,
-      cmc: card.cmc,
-      type_line: card.type_line,
-      oracle_text: card.oracle_text,
-      colors: card.colors,
-      color_identity: card.color_identity,
-    };
-
     try {
-      await addCardToDeck(selectedDeck, cardData);
+      await addCardToDeck(selectedDeck, card.id, 'main');
       setAddCardMessage(`Successfully added ${card.name} to your deck!`);
     } catch (err) {
       setAddCardMessage(`Failed to add ${card.name}.`);
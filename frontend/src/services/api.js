@@ -20,16 +20,68 @@ export const getDeck = (deckId) => api.get(`/decks/${deckId}`);
 export const updateDeck = (deckId, deckData) => api.put(`/decks/${deckId}`, deckData);
 export const deleteDeck = (deckId) => api.delete(`/decks/${deckId}`);
 export const setDeckVisibility = (deckId, isPublic) => api.put(`/decks/${deckId}/visibility`, { is_public: isPublic });
+export const setDeckBudget = (deckId, budget) => api.put(`/decks/${deckId}/budget`, { budget });
+export const getDeckStats = (deckId) => api.get(`/decks/${deckId}/stats`);
+export const getDrawOdds = (deckId, query) => api.post(`/decks/${deckId}/odds`, query);
+export const getSampleHand = (deckId, seed) => api.get(`/decks/${deckId}/sample-hand`, { params: seed === undefined ? {} : { seed } });
+export const simulateMulligans = (deckId, options) => api.post(`/decks/${deckId}/simulate`, options);
+export const goldfishDeck = (deckId, params = {}) => api.get(`/decks/${deckId}/goldfish`, { params });
+export const getDeckValue = (deckId, currency = 'usd') => api.get(`/decks/${deckId}/value`, { params: { currency } });
+export const forkDeck = (deckId, name) => api.post(`/decks/${deckId}/fork`, name ? { name } : {});
+export const compareDecks = (a, b) => api.get('/decks/compare', { params: { a, b } });
 
 // --- Deck Cards ---
-export const addCardToDeck = (deckId, cardData, board) => api.post(`/decks/${deckId}/cards`, { card: cardData, board: board });
-export const removeCardFromDeck = (deckId, cardId) => api.delete(`/decks/${deckId}/cards/${cardId}`);
+export const addCardToDeck = (deckId, scryfallId, board) => api.post(`/decks/${deckId}/cards`, { scryfall_id: scryfallId, board: board });
+export const removeCardFromDeck = (deckId, cardId, board = 'main') => api.delete(`/decks/${deckId}/cards/${cardId}`, { params: { board } });
+export const setCardQuantity = (deckId, cardId, board, quantity) => api.patch(`/decks/${deckId}/cards/${cardId}`, { board, quantity });
+export const moveCard = (deckId, cardId, from, to, quantity) => api.post(`/decks/${deckId}/cards/${cardId}/move`, { from, to, quantity });
+export const setCardTags = (deckId, cardId, board, tags) => api.patch(`/decks/${deckId}/cards/${cardId}/tags`, { board, tags });
+export const updateDeckEntry = (deckId, cardId, board, changes) => api.patch(`/decks/${deckId}/cards/${cardId}/entry`, { board, ...changes });
+export const bulkTagCards = (deckId, cards, add = [], remove = []) => api.post(`/decks/${deckId}/custom-tags`, { cards, add, remove });
+export const getDeckByTag = (deckId, board) => api.get(`/decks/${deckId}/by-tag`, { params: board ? { board } : {} });
+export const createBoard = (deckId, name) => api.post(`/decks/${deckId}/boards`, { name });
+export const deleteBoard = (deckId, board) => api.delete(`/decks/${deckId}/boards/${encodeURIComponent(board)}`);
+export const setBoardOrder = (deckId, board, cardIds) => api.put(`/decks/${deckId}/boards/${encodeURIComponent(board)}/order`, { card_ids: cardIds });
+export const getDeckOwnership = (deckId) => api.get(`/decks/${deckId}/ownership`);
+
+// --- Deck Health ---
+export const getDeckHealth = (deckId, template) => api.get(`/decks/${deckId}/health`, { params: template ? { template } : {} });
+export const getDeckCombos = (deckId) => api.get(`/decks/${deckId}/combos`);
+export const getHealthTemplates = (format) => api.get('/health-templates/', { params: format ? { format } : {} });
+export const createHealthTemplate = (template) => api.post('/health-templates/', template);
+export const updateHealthTemplate = (templateId, template) => api.put(`/health-templates/${templateId}`, template);
+export const deleteHealthTemplate = (templateId) => api.delete(`/health-templates/${templateId}`);
+
+// --- Collections ---
+export const getCollections = () => api.get('/collections/');
+export const createCollection = (name) => api.post('/collections/', { name });
+export const getCollection = (collectionId) => api.get(`/collections/${collectionId}`);
+export const renameCollection = (collectionId, name) => api.put(`/collections/${collectionId}`, { name });
+export const deleteCollection = (collectionId) => api.delete(`/collections/${collectionId}`);
+export const addCollectionCard = (collectionId, card) => api.post(`/collections/${collectionId}/cards`, card);
+export const updateCollectionCard = (collectionId, entryId, changes) => api.patch(`/collections/${collectionId}/cards/${entryId}`, changes);
+export const removeCollectionCard = (collectionId, entryId) => api.delete(`/collections/${collectionId}/cards/${entryId}`);
+export const importCollection = (collectionId, text, options = {}) => api.post(`/collections/${collectionId}/import`, { text, ...options });
+export const importCollectionCsv = (collectionId, file, replace = false) => {
+  const form = new FormData();
+  form.append('file', file);
+  return api.post(`/collections/${collectionId}/import/csv`, form, {
+    params: { replace },
+    headers: { 'Content-Type': 'multipart/form-data' },
+  });
+};
+export const exportCollectionCsv = (collectionId) => api.get(`/collections/${collectionId}/export/csv`, { responseType: 'blob' });
 
 // --- Profiles ---
 export const getUserProfile = (username) => api.get(`/profiles/${username}`);
 
-// --- Scryfall API ---
-const scryfallApi = axios.create({ baseURL: 'https://api.scryfall.com' });
-export const searchScryfall = (query) => scryfallApi.get(`/cards/search?q=${encodeURIComponent(query)}`);
+// --- Cards ---
+// Searches go through our backend, which proxies Scryfall.
+export const searchCards = (query) => api.get(`/cards/search?q=${encodeURIComponent(query)}`);
+export const getTags = () => api.get('/tags');
+
+// Older cached cards store image_uris as a JSON string; newer ones as an object.
+export const parseImageUris = (imageUris) =>
+  typeof imageUris === 'string' ? JSON.parse(imageUris) : imageUris;
 
 export default api;